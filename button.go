@@ -0,0 +1,261 @@
+package deconz
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
+// ButtonAction describes what was done to a button.
+type ButtonAction int
+
+// The actions which can be reported by a switch. The first values match the last three digits of the button event codes.
+const (
+	ButtonInitialPress ButtonAction = iota
+	ButtonHold
+	ButtonShortRelease
+	ButtonLongRelease
+	ButtonDoublePress
+	ButtonTriplePress
+	ButtonQuadruplePress
+	ButtonShake
+	ButtonDrop
+	ButtonTilt
+	ButtonManyPress
+
+	// The following are reported by the cubes, using the gesture rather than the event code
+	ButtonWake
+	ButtonFlip90
+	ButtonFlip180
+	ButtonPush
+	ButtonRotateClockwise
+	ButtonRotateCounterClockwise
+
+	ButtonUnknown ButtonAction = -1
+)
+
+var buttonActionNames = map[ButtonAction]string{
+	ButtonInitialPress:           "initial press",
+	ButtonHold:                   "hold",
+	ButtonShortRelease:           "short release",
+	ButtonLongRelease:            "long release",
+	ButtonDoublePress:            "double press",
+	ButtonTriplePress:            "triple press",
+	ButtonQuadruplePress:         "quadruple press",
+	ButtonShake:                  "shake",
+	ButtonDrop:                   "drop",
+	ButtonTilt:                   "tilt",
+	ButtonManyPress:              "many press",
+	ButtonWake:                   "wake",
+	ButtonFlip90:                 "flip 90",
+	ButtonFlip180:                "flip 180",
+	ButtonPush:                   "push",
+	ButtonRotateClockwise:        "rotate clockwise",
+	ButtonRotateCounterClockwise: "rotate counter-clockwise",
+}
+
+// String returns the name of the action, such as 'short release'.
+func (a ButtonAction) String() string {
+	if name, ok := buttonActionNames[a]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ButtonEvent contains a decoded switch event.
+type ButtonEvent struct {
+	// Button is the number of the button, or the side of a cube, starting from 1
+	Button int
+	// Name is the label of the button, if the model is known
+	Name   string
+	Action ButtonAction
+	// Angle contains the rotation of a cube in degrees; it is only set for the rotate actions
+	Angle int
+	// Code contains the event code reported by the gateway
+	Code int
+}
+
+// ButtonMapping describes the buttons of a switch model.
+type ButtonMapping struct {
+	// Buttons contains the label of each button, by number
+	Buttons map[int]string
+	// Decode replaces the default decoding, which splits the event code into the button and the action, if set
+	Decode func(state ZHASwitch) (ButtonEvent, bool)
+}
+
+var (
+	buttonMappingsMu sync.RWMutex
+	// buttonMappings contains the mappings of the known switch models.
+	buttonMappings = map[string]ButtonMapping{
+		// Philips Hue dimmer switch
+		"RWL020": {Buttons: map[int]string{1: "on", 2: "dim up", 3: "dim down", 4: "off"}},
+		"RWL021": {Buttons: map[int]string{1: "on", 2: "dim up", 3: "dim down", 4: "off"}},
+		"RWL022": {Buttons: map[int]string{1: "on/off", 2: "dim up", 3: "dim down", 4: "hue"}},
+		// Philips Hue smart button
+		"ROM001": {Buttons: map[int]string{1: "button"}},
+		// Philips Hue tap, which reports its own codes
+		"ZGPSWITCH": {Buttons: map[int]string{5: "3 and 4"}, Decode: decodeHueTap},
+		// IKEA TRÅDFRI remotes
+		"TRADFRI remote control":    {Buttons: map[int]string{1: "power", 2: "dim up", 3: "dim down", 4: "previous", 5: "next"}},
+		"TRADFRI on/off switch":     {Buttons: map[int]string{1: "on", 2: "off"}},
+		"TRADFRI SHORTCUT Button":   {Buttons: map[int]string{1: "button"}},
+		"Remote Control N2":         {Buttons: map[int]string{1: "on", 2: "off", 3: "dim up", 4: "dim down"}},
+		"TRADFRI open/close remote": {Buttons: map[int]string{1: "open", 2: "close"}},
+		// Xiaomi Aqara switches
+		"lumi.sensor_switch":      {Buttons: map[int]string{1: "button"}},
+		"lumi.sensor_switch.aq2":  {Buttons: map[int]string{1: "button"}},
+		"lumi.remote.b1acn01":     {Buttons: map[int]string{1: "button"}},
+		"lumi.remote.b186acn01":   {Buttons: map[int]string{1: "button"}},
+		"lumi.remote.b286acn01":   {Buttons: map[int]string{1: "left", 2: "right", 3: "both"}},
+		"lumi.sensor_86sw2":       {Buttons: map[int]string{1: "left", 2: "right", 3: "both"}},
+		"lumi.sensor_cube":        {Decode: decodeCube},
+		"lumi.sensor_cube.aqgl01": {Decode: decodeCube},
+	}
+)
+
+// RegisterButtonMapping records the buttons of a switch model, replacing any existing mapping.
+func RegisterButtonMapping(modelID string, mapping ButtonMapping) {
+	buttonMappingsMu.Lock()
+	defer buttonMappingsMu.Unlock()
+
+	buttonMappings[modelID] = mapping
+}
+
+func buttonMapping(modelID string) (ButtonMapping, bool) {
+	buttonMappingsMu.RLock()
+	defer buttonMappingsMu.RUnlock()
+
+	mapping, ok := buttonMappings[modelID]
+	return mapping, ok
+}
+
+// DecodeButtonEvent decodes the event reported by a switch, using the mapping of the model if it is known.
+// False is returned if the state doesn't contain an event.
+func DecodeButtonEvent(modelID string, state ZHASwitch) (ButtonEvent, bool) {
+	mapping, hasMapping := buttonMapping(modelID)
+	if hasMapping && mapping.Decode != nil {
+		event, ok := mapping.Decode(state)
+		if ok {
+			event.Name = mapping.Buttons[event.Button]
+		}
+		return event, ok
+	}
+
+	if state.ButtonEvent < 1000 {
+		return ButtonEvent{}, false
+	}
+
+	event := ButtonEvent{
+		Button: state.ButtonEvent / 1000,
+		Action: ButtonAction(state.ButtonEvent % 1000),
+		Code:   state.ButtonEvent,
+	}
+	if event.Action > ButtonManyPress {
+		event.Action = ButtonUnknown
+	}
+	if hasMapping {
+		event.Name = mapping.Buttons[event.Button]
+	}
+	return event, true
+}
+
+// ButtonEvent decodes the last event reported by a switch sensor.
+// False is returned if the sensor isn't a switch.
+func (s *Sensor) ButtonEvent() (ButtonEvent, bool) {
+	switch {
+	case s.SwitchState != nil:
+		return DecodeButtonEvent(s.ModelID, *s.SwitchState)
+	case s.ButtonState != nil:
+		return DecodeButtonEvent(s.ModelID, ZHASwitch{ButtonEvent: s.ButtonState.ButtonEvent})
+	}
+	return ButtonEvent{}, false
+}
+
+// ButtonEvent decodes the event in a switch update. The websocket doesn't include the model of the sensor,
+// so it has to be supplied; it is available from the cached sensor with the same ID.
+// False is returned if the update doesn't contain a button event.
+func (wsu *WebsocketUpdate) ButtonEvent(modelID string) (ButtonEvent, bool) {
+	if wsu.SensorState == nil {
+		return ButtonEvent{}, false
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(wsu.Meta.State, &fields); err != nil {
+		return ButtonEvent{}, false
+	}
+	if _, ok := fields["buttonevent"]; !ok {
+		return ButtonEvent{}, false
+	}
+
+	return DecodeButtonEvent(modelID, ZHASwitch{
+		ButtonEvent:   wsu.SensorState.ButtonEvent,
+		Gesture:       wsu.SensorState.Gesture,
+		EventDuration: wsu.SensorState.EventDuration,
+		X:             wsu.SensorState.X,
+		Y:             wsu.SensorState.Y,
+		Angle:         wsu.SensorState.Angle,
+	})
+}
+
+// hueTapButtons maps the codes reported by the Hue tap to its buttons.
+var hueTapButtons = map[int]int{34: 1, 16: 2, 17: 3, 18: 4, 98: 5}
+
+func decodeHueTap(state ZHASwitch) (ButtonEvent, bool) {
+	button, ok := hueTapButtons[state.ButtonEvent]
+	if !ok {
+		return ButtonEvent{}, false
+	}
+	return ButtonEvent{
+		Button: button,
+		Action: ButtonInitialPress,
+		Code:   state.ButtonEvent,
+	}, true
+}
+
+// cubeGestures maps the gestures reported by the cubes to actions.
+var cubeGestures = map[int]ButtonAction{
+	0: ButtonWake,
+	1: ButtonShake,
+	2: ButtonDrop,
+	3: ButtonFlip90,
+	4: ButtonFlip180,
+	5: ButtonPush,
+	6: ButtonDoublePress,
+	7: ButtonRotateClockwise,
+	8: ButtonRotateCounterClockwise,
+}
+
+// decodeCube decodes the cube events using the gesture. The side facing up is reported in the thousands
+// of the event code, except for rotations which report the angle in hundredths of a degree instead.
+func decodeCube(state ZHASwitch) (ButtonEvent, bool) {
+	action, ok := cubeGestures[state.Gesture]
+	if !ok {
+		return ButtonEvent{}, false
+	}
+
+	event := ButtonEvent{
+		Action: action,
+		Code:   state.ButtonEvent,
+	}
+	switch action {
+	case ButtonRotateClockwise, ButtonRotateCounterClockwise:
+		event.Angle = state.ButtonEvent / 100
+	case ButtonWake, ButtonShake, ButtonDrop:
+	default:
+		event.Button = state.ButtonEvent / 1000
+	}
+	return event, true
+}
+
+// String formats the event for display, for example 'dim up (2) hold'.
+func (e ButtonEvent) String() string {
+	switch {
+	case e.Action == ButtonRotateClockwise || e.Action == ButtonRotateCounterClockwise:
+		return e.Action.String() + " " + strconv.Itoa(e.Angle) + "°"
+	case e.Button < 1:
+		return e.Action.String()
+	case len(e.Name) > 0:
+		return e.Name + " (" + strconv.Itoa(e.Button) + ") " + e.Action.String()
+	}
+	return strconv.Itoa(e.Button) + " " + e.Action.String()
+}
@@ -0,0 +1,412 @@
+// Package cache maintains an in-memory mirror of the lights, groups and sensors on a deCONZ gateway.
+// The mirror is loaded through the REST API and kept current by applying the websocket updates.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/rmrobinson/deconz-go"
+)
+
+// Event describes a change which was applied to the cache.
+// The resource field matching Update.Meta.Resource contains a copy of the resource after the change;
+// it is nil if the resource was deleted.
+type Event struct {
+	Update *deconz.WebsocketUpdate
+
+	Group  *deconz.Group
+	Light  *deconz.Light
+	Sensor *deconz.Sensor
+}
+
+// Cache contains the last known state of the gateway resources. It is safe for concurrent use.
+type Cache struct {
+	client *deconz.Client
+
+	// OnError is called, if set, when Run loses the websocket connection or fails to resynchronise the cache.
+	OnError func(error)
+
+	mu      sync.RWMutex
+	groups  map[string]deconz.Group
+	lights  map[string]deconz.Light
+	sensors map[string]deconz.Sensor
+	// loading counts the loads retrieving the gateway state, and applied records the updates applied meanwhile.
+	loading int
+	applied []*deconz.WebsocketUpdate
+
+	subMu       sync.Mutex
+	subscribers map[int]func(Event)
+	nextSubID   int
+}
+
+// New creates a new, empty cache which uses the supplied client to load the gateway state.
+func New(client *deconz.Client) *Cache {
+	return &Cache{
+		client:      client,
+		groups:      map[string]deconz.Group{},
+		lights:      map[string]deconz.Light{},
+		sensors:     map[string]deconz.Sensor{},
+		subscribers: map[int]func(Event){},
+	}
+}
+
+// Load replaces the contents of the cache with the current state retrieved from the gateway.
+// Updates applied while the state is retrieved are applied again on top of it.
+// Subscribers are not notified; use Resync to be told about the differences.
+func (c *Cache) Load(ctx context.Context) error {
+	_, err := c.load(ctx, false)
+	return err
+}
+
+// Run keeps the cache current using the gateway websocket.
+// Every time the websocket (re)connects the cache is resynchronised, so changes made while it was down are not missed.
+// This includes the first connection, which loads the cache; if it wasn't loaded beforehand,
+// subscribers are notified that every resource was added.
+// It blocks until the context is cancelled and returns the context error.
+func (c *Cache) Run(ctx context.Context) error {
+	wsc := deconz.NewWebsocketClient(c.client)
+	wsc.OnError = c.OnError
+	wsc.OnConnect = func() {
+		// This runs before any messages are read from the new connection, so the
+		// updates received afterwards are applied on top of the fresh snapshot.
+		if err := c.Resync(ctx); err != nil && c.OnError != nil {
+			c.OnError(err)
+		}
+	}
+
+	return wsc.Run(ctx, c.Apply)
+}
+
+// Subscribe registers a handler which is called after every change applied to the cache.
+// Handlers are called synchronously, in the order changes are applied, so they should not block.
+// The returned function removes the subscription.
+func (c *Cache) Subscribe(handler func(Event)) func() {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	id := c.nextSubID
+	c.nextSubID++
+	c.subscribers[id] = handler
+
+	return func() {
+		c.subMu.Lock()
+		defer c.subMu.Unlock()
+		delete(c.subscribers, id)
+	}
+}
+
+// Group retrieves a copy of the specified group.
+func (c *Cache) Group(id string) (deconz.Group, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	group, ok := c.groups[id]
+	return group, ok
+}
+
+// Groups retrieves a copy of all the groups.
+func (c *Cache) Groups() deconz.GetGroupsResponse {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ret := deconz.GetGroupsResponse{}
+	for id, group := range c.groups {
+		ret[id] = group
+	}
+	return ret
+}
+
+// Light retrieves a copy of the specified light.
+func (c *Cache) Light(id string) (deconz.Light, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	light, ok := c.lights[id]
+	return light, ok
+}
+
+// Lights retrieves a copy of all the lights.
+func (c *Cache) Lights() deconz.GetLightsResponse {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ret := deconz.GetLightsResponse{}
+	for id, light := range c.lights {
+		ret[id] = light
+	}
+	return ret
+}
+
+// Sensor retrieves a copy of the specified sensor.
+func (c *Cache) Sensor(id string) (deconz.Sensor, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	sensor, ok := c.sensors[id]
+	return sensor, ok
+}
+
+// Sensors retrieves a copy of all the sensors.
+func (c *Cache) Sensors() deconz.GetSensorsResponse {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ret := deconz.GetSensorsResponse{}
+	for id, sensor := range c.sensors {
+		ret[id] = sensor
+	}
+	return ret
+}
+
+// DecodeSensors decodes the typed state of every cached sensor again from its raw state and config,
+// so decoders registered after the sensors were loaded are applied. Subscribers are not notified.
+// Every sensor is decoded; the first failure is returned, and each is kept in the DecodeErr of its sensor.
+func (c *Cache) DecodeSensors() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var ret error
+	for id, sensor := range c.sensors {
+		if err := sensor.Decode(); err != nil && ret == nil {
+			ret = err
+		}
+		c.sensors[id] = sensor
+	}
+	return ret
+}
+
+// Apply merges the supplied websocket update into the cache and notifies the subscribers.
+// Updates for resources which aren't tracked, or which can't be merged, are ignored.
+func (c *Cache) Apply(update *deconz.WebsocketUpdate) {
+	if update.Meta.Type != "event" {
+		return
+	}
+
+	c.mu.Lock()
+	event, ok := c.apply(update)
+	if ok && c.loading > 0 {
+		c.applied = append(c.applied, update)
+	}
+	c.mu.Unlock()
+
+	if ok {
+		c.notify(event)
+	}
+}
+
+func (c *Cache) apply(update *deconz.WebsocketUpdate) (Event, bool) {
+	event := Event{Update: update}
+	id := update.Meta.ResourceID
+
+	switch update.Meta.Resource {
+	case "groups":
+		switch update.Meta.Event {
+		case "added":
+			if update.Group == nil {
+				return event, false
+			}
+			group := *update.Group
+			group.ID = id
+			c.groups[id] = group
+			event.Group = &group
+		case "changed":
+			group, ok := c.groups[id]
+			if !ok {
+				return event, false
+			}
+			if err := mergeGroup(&group, update.Meta); err != nil {
+				return event, false
+			}
+			c.groups[id] = group
+			event.Group = &group
+		case "deleted":
+			if _, ok := c.groups[id]; !ok {
+				return event, false
+			}
+			delete(c.groups, id)
+		default:
+			return event, false
+		}
+	case "lights":
+		switch update.Meta.Event {
+		case "added":
+			if update.Light == nil {
+				return event, false
+			}
+			light := *update.Light
+			light.ID = id
+			c.lights[id] = light
+			event.Light = &light
+		case "changed":
+			light, ok := c.lights[id]
+			if !ok {
+				return event, false
+			}
+			if err := mergeLight(&light, update.Meta); err != nil {
+				return event, false
+			}
+			c.lights[id] = light
+			event.Light = &light
+		case "deleted":
+			if _, ok := c.lights[id]; !ok {
+				return event, false
+			}
+			delete(c.lights, id)
+		default:
+			return event, false
+		}
+	case "sensors":
+		switch update.Meta.Event {
+		case "added":
+			if update.Sensor == nil {
+				return event, false
+			}
+			sensor := *update.Sensor
+			sensor.ID = id
+			c.sensors[id] = sensor
+			event.Sensor = &sensor
+		case "changed":
+			sensor, ok := c.sensors[id]
+			if !ok {
+				return event, false
+			}
+			merged, err := mergeSensor(sensor, update.Meta)
+			if err != nil {
+				return event, false
+			}
+			c.sensors[id] = *merged
+			event.Sensor = merged
+		case "deleted":
+			if _, ok := c.sensors[id]; !ok {
+				return event, false
+			}
+			delete(c.sensors, id)
+		default:
+			return event, false
+		}
+	default:
+		return event, false
+	}
+
+	return event, true
+}
+
+func (c *Cache) notify(event Event) {
+	c.subMu.Lock()
+	handlers := make([]func(Event), 0, len(c.subscribers))
+	// Handlers are called in subscription order
+	for i := 0; i < c.nextSubID; i++ {
+		if handler, ok := c.subscribers[i]; ok {
+			handlers = append(handlers, handler)
+		}
+	}
+	c.subMu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// mergeGroup applies a partial group update. The websocket only includes the fields which changed,
+// so the payload is decoded on top of the existing values.
+func mergeGroup(group *deconz.Group, meta deconz.WebsocketUpdateMetadata) error {
+	if len(meta.State) > 0 {
+		if err := json.Unmarshal(meta.State, &group.State); err != nil {
+			return err
+		}
+	}
+	if len(meta.Name) > 0 {
+		group.Name = meta.Name
+	}
+	return nil
+}
+
+// mergeLight applies a partial light update. The websocket only includes the fields which changed,
+// so the payload is decoded on top of the existing values.
+func mergeLight(light *deconz.Light, meta deconz.WebsocketUpdateMetadata) error {
+	if len(meta.State) > 0 {
+		// Decoding into a slice or pointer reuses its storage; copy them so readers holding the old value aren't affected
+		light.State.XY = append([]float64(nil), light.State.XY...)
+		light.State.Open = copyBool(light.State.Open)
+		light.State.Lift = copyInt(light.State.Lift)
+		light.State.Tilt = copyInt(light.State.Tilt)
+
+		if err := json.Unmarshal(meta.State, &light.State); err != nil {
+			return err
+		}
+	}
+	if len(meta.Name) > 0 {
+		light.Name = meta.Name
+	}
+	return nil
+}
+
+func copyBool(v *bool) *bool {
+	if v == nil {
+		return nil
+	}
+	ret := *v
+	return &ret
+}
+
+func copyInt(v *int) *int {
+	if v == nil {
+		return nil
+	}
+	ret := *v
+	return &ret
+}
+
+// mergeSensor applies a partial sensor update. The websocket only includes the fields which changed,
+// so the raw state and config are merged key by key and the typed sensor is then decoded from the result.
+func mergeSensor(sensor deconz.Sensor, meta deconz.WebsocketUpdateMetadata) (*deconz.Sensor, error) {
+	if len(meta.State) > 0 {
+		state, err := mergeRaw(sensor.StateRaw, meta.State)
+		if err != nil {
+			return nil, err
+		}
+		sensor.StateRaw = state
+	}
+	if len(meta.Config) > 0 {
+		config, err := mergeRaw(sensor.ConfigRaw, meta.Config)
+		if err != nil {
+			return nil, err
+		}
+		sensor.ConfigRaw = config
+		if err := json.Unmarshal(meta.Config, &sensor.Config); err != nil {
+			return nil, err
+		}
+	}
+	if len(meta.Name) > 0 {
+		sensor.Name = meta.Name
+	}
+
+	// The typed state is decoded from the merged raw JSON, so nothing the gateway didn't report is added.
+	// A sensor whose typed state can't be decoded is still cached, with the error in DecodeErr.
+	merged := &deconz.Sensor{SensorMetadata: sensor.SensorMetadata}
+	_ = merged.Decode()
+
+	return merged, nil
+}
+
+// mergeRaw overlays the keys of the update JSON object on top of the keys of the original JSON object.
+func mergeRaw(original, update json.RawMessage) (json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if len(original) > 0 {
+		if err := json.Unmarshal(original, &fields); err != nil {
+			return nil, err
+		}
+	}
+
+	updateFields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(update, &updateFields); err != nil {
+		return nil, err
+	}
+	for k, v := range updateFields {
+		fields[k] = v
+	}
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,178 @@
+package cache_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/deconz-go"
+	"github.com/rmrobinson/deconz-go/cache"
+	"github.com/rmrobinson/deconz-go/deconztest"
+)
+
+func TestResyncAfterReconnect(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+
+	hallID := server.AddLight(deconz.Light{Name: "Hall", Type: "Dimmable light", UniqueID: "00:11:22:33:44:55:66:77-01"})
+
+	c := cache.New(server.Client())
+	events := make(chan cache.Event, 100)
+	c.Subscribe(func(event cache.Event) {
+		events <- event
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	// Changes are only seen once the websocket is connected, so keep switching the light until one arrives
+	connected := false
+	for on := true; !connected; on = !on {
+		server.UpdateLightState(hallID, map[string]interface{}{"on": on})
+		select {
+		case event := <-events:
+			connected = event.Light != nil && event.Light.ID == hallID
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	// Nothing is announced while the connection is down, so these are only seen through the resync
+	server.DropWebsockets()
+	server.UpdateLightState(hallID, map[string]interface{}{"bri": 42})
+	kitchenID := server.AddLight(deconz.Light{Name: "Kitchen", Type: "Dimmable light", UniqueID: "00:11:22:33:44:55:66:88-01"})
+
+	added, changed := false, false
+	timeout := time.After(5 * time.Second)
+	for !added || !changed {
+		select {
+		case event := <-events:
+			if event.Light == nil {
+				continue
+			}
+			switch {
+			case event.Update.Meta.Event == "added" && event.Light.ID == kitchenID:
+				added = true
+			case event.Update.Meta.Event == "changed" && event.Light.ID == hallID && event.Light.State.Brightness == 42:
+				changed = true
+			}
+		case <-timeout:
+			t.Fatalf("resync after reconnecting reported added %t and changed %t, want both", added, changed)
+		}
+	}
+
+	if light, ok := c.Light(kitchenID); !ok || light.Name != "Kitchen" {
+		t.Errorf("cached light %s = %+v, %t, want the light added while disconnected", kitchenID, light, ok)
+	}
+	if light, ok := c.Light(hallID); !ok || light.State.Brightness != 42 {
+		t.Errorf("cached light %s = %+v, %t, want the brightness set while disconnected", hallID, light, ok)
+	}
+}
+
+func TestApplySensorKeepsConfig(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+
+	// A mains powered sensor doesn't report a battery level
+	sensor := deconz.Sensor{}
+	sensor.Name = "Living room"
+	sensor.Type = "ZHATemperature"
+	sensor.UniqueID = "00:11:22:33:44:55:66:bb-01-0402"
+	sensor.Config = deconz.SensorConfig{On: true, Reachable: true}
+	sensor.StateRaw = json.RawMessage(`{"temperature":2100,"lastupdated":"none"}`)
+	sensor.ConfigRaw = json.RawMessage(`{"on":true,"reachable":true,"offset":0}`)
+	id := server.AddSensor(sensor)
+
+	c := cache.New(server.Client())
+	if err := c.Load(context.Background()); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	update := &deconz.WebsocketUpdate{}
+	msg := `{"t":"event","e":"changed","r":"sensors","id":"` + id + `","state":{"temperature":2150}}`
+	if err := json.Unmarshal([]byte(msg), update); err != nil {
+		t.Fatalf("unmarshal returned error: %v", err)
+	}
+	c.Apply(update)
+
+	cached, ok := c.Sensor(id)
+	if !ok {
+		t.Fatalf("sensor %s isn't cached", id)
+	}
+	got := cached.Measurements()
+	if len(got) != 1 || got[0].Quantity != deconz.QuantityTemperature || got[0].Value != 21.5 {
+		t.Errorf("Measurements() after an update = %+v, want only the temperature of 21.5", got)
+	}
+
+	config := map[string]interface{}{}
+	if err := json.Unmarshal(cached.ConfigRaw, &config); err != nil {
+		t.Fatalf("unmarshal of the cached config returned error: %v", err)
+	}
+	if _, ok := config["battery"]; ok {
+		t.Errorf("cached config = %s, want no battery", cached.ConfigRaw)
+	}
+	if _, ok := config["offset"]; !ok {
+		t.Errorf("cached config = %s, want the offset kept", cached.ConfigRaw)
+	}
+}
+
+// hookTransport calls a function before forwarding each request.
+type hookTransport struct {
+	before func(*http.Request)
+	next   http.RoundTripper
+}
+
+func (ht *hookTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ht.before(r)
+	return ht.next.RoundTrip(r)
+}
+
+func TestResyncKeepsUpdatesAppliedDuringFetch(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+
+	hallID := server.AddLight(deconz.Light{Name: "Hall", Type: "Dimmable light", UniqueID: "00:11:22:33:44:55:66:77-01"})
+
+	var c *cache.Cache
+	hooked := true
+	transport := &hookTransport{next: http.DefaultTransport}
+	transport.before = func(r *http.Request) {
+		// The lights were already retrieved, so the snapshot predates this update
+		if !hooked && strings.HasSuffix(r.URL.Path, "/sensors") {
+			hooked = true
+			update := &deconz.WebsocketUpdate{}
+			msg := `{"t":"event","e":"changed","r":"lights","id":"` + hallID + `","state":{"on":true,"bri":42}}`
+			if err := json.Unmarshal([]byte(msg), update); err != nil {
+				t.Errorf("unmarshal returned error: %v", err)
+				return
+			}
+			c.Apply(update)
+		}
+	}
+	c = cache.New(deconz.NewClient(&http.Client{Transport: transport}, server.Host(), server.Port(), deconztest.DefaultAPIKey))
+	if err := c.Load(context.Background()); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	var events []cache.Event
+	c.Subscribe(func(event cache.Event) {
+		events = append(events, event)
+	})
+	hooked = false
+	if err := c.Resync(context.Background()); err != nil {
+		t.Fatalf("Resync returned error: %v", err)
+	}
+
+	if light, ok := c.Light(hallID); !ok || light.State.Brightness != 42 || !light.State.On {
+		t.Errorf("cached light %s = %+v, %t, want the update applied during the resync kept", hallID, light, ok)
+	}
+	// The update was announced when it was applied, and the resync doesn't undo it
+	if len(events) != 1 || events[0].Light == nil || events[0].Light.State.Brightness != 42 {
+		t.Errorf("resync events = %+v, want only the applied update", events)
+	}
+}
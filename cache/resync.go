@@ -0,0 +1,318 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/rmrobinson/deconz-go"
+)
+
+// snapshot contains the state of the gateway resources at a point in time.
+type snapshot struct {
+	groups  map[string]deconz.Group
+	lights  map[string]deconz.Light
+	sensors map[string]deconz.Sensor
+}
+
+func (c *Cache) snapshot(ctx context.Context) (*snapshot, error) {
+	groups, err := c.client.GetGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lights, err := c.client.GetLights(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sensors, err := c.client.GetSensors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{
+		groups:  map[string]deconz.Group{},
+		lights:  lights,
+		sensors: sensors,
+	}
+	for id, group := range *groups {
+		group.ID = id
+		snap.groups[id] = group
+	}
+
+	return snap, nil
+}
+
+// Resync retrieves the current state from the gateway and replaces the contents of the cache with it.
+// Subscribers are notified of every difference with a synthetic 'added', 'changed' or 'deleted' update,
+// in the same form as the websocket would have sent it.
+// Updates applied while the state is retrieved are applied again on top of it, as the state may predate them.
+func (c *Cache) Resync(ctx context.Context) error {
+	events, err := c.load(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		c.notify(event)
+	}
+
+	return nil
+}
+
+// load replaces the contents of the cache with a fresh snapshot. The updates applied while the snapshot
+// was retrieved are applied to it again, so they aren't lost if the gateway state predates them.
+// If diff is set, the events describing the differences from the previous contents are returned.
+func (c *Cache) load(ctx context.Context, diff bool) ([]Event, error) {
+	c.mu.Lock()
+	c.loading++
+	start := len(c.applied)
+	c.mu.Unlock()
+
+	snap, err := c.snapshot(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	applied := c.applied[start:]
+	c.loading--
+	if c.loading == 0 {
+		c.applied = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	old := &snapshot{groups: c.groups, lights: c.lights, sensors: c.sensors}
+	c.groups = snap.groups
+	c.lights = snap.lights
+	c.sensors = snap.sensors
+	for _, update := range applied {
+		c.apply(update)
+	}
+
+	if !diff {
+		return nil, nil
+	}
+
+	var events []Event
+	events = append(events, diffGroups(old.groups, c.groups)...)
+	events = append(events, diffLights(old.lights, c.lights)...)
+	events = append(events, diffSensors(old.sensors, c.sensors)...)
+	return events, nil
+}
+
+func diffGroups(old, current map[string]deconz.Group) []Event {
+	var events []Event
+
+	for _, id := range sortedIDs(old, current) {
+		oldGroup, hadGroup := old[id]
+		group, hasGroup := current[id]
+
+		meta := deconz.WebsocketUpdateMetadata{
+			Type:       "event",
+			Resource:   "groups",
+			ResourceID: id,
+		}
+
+		switch {
+		case !hasGroup:
+			meta.Event = "deleted"
+		case !hadGroup:
+			meta.Event = "added"
+			meta.Group = rawJSON(group)
+		default:
+			meta.Event = "changed"
+			if !reflect.DeepEqual(oldGroup.State, group.State) {
+				meta.State = rawJSON(group.State)
+			}
+			if oldGroup.Name != group.Name {
+				meta.Name = group.Name
+			}
+			if len(meta.State) < 1 && len(meta.Name) < 1 {
+				continue
+			}
+		}
+
+		update, err := newUpdate(meta)
+		if err != nil {
+			continue
+		}
+
+		event := Event{Update: update}
+		if hasGroup {
+			group := group
+			event.Group = &group
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func diffLights(old, current map[string]deconz.Light) []Event {
+	var events []Event
+
+	for _, id := range sortedIDs(old, current) {
+		oldLight, hadLight := old[id]
+		light, hasLight := current[id]
+
+		meta := deconz.WebsocketUpdateMetadata{
+			Type:       "event",
+			Resource:   "lights",
+			ResourceID: id,
+		}
+
+		switch {
+		case !hasLight:
+			meta.Event = "deleted"
+			meta.UniqueID = oldLight.UniqueID
+		case !hadLight:
+			meta.Event = "added"
+			meta.UniqueID = light.UniqueID
+			meta.Light = rawJSON(light)
+		default:
+			meta.Event = "changed"
+			meta.UniqueID = light.UniqueID
+			if !reflect.DeepEqual(oldLight.State, light.State) {
+				meta.State = rawJSON(light.State)
+			}
+			if oldLight.Name != light.Name {
+				meta.Name = light.Name
+			}
+			if len(meta.State) < 1 && len(meta.Name) < 1 {
+				continue
+			}
+		}
+
+		update, err := newUpdate(meta)
+		if err != nil {
+			continue
+		}
+
+		event := Event{Update: update}
+		if hasLight {
+			light := light
+			event.Light = &light
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func diffSensors(old, current map[string]deconz.Sensor) []Event {
+	var events []Event
+
+	for _, id := range sortedIDs(old, current) {
+		oldSensor, hadSensor := old[id]
+		sensor, hasSensor := current[id]
+
+		meta := deconz.WebsocketUpdateMetadata{
+			Type:       "event",
+			Resource:   "sensors",
+			ResourceID: id,
+		}
+
+		switch {
+		case !hasSensor:
+			meta.Event = "deleted"
+			meta.UniqueID = oldSensor.UniqueID
+		case !hadSensor:
+			meta.Event = "added"
+			meta.UniqueID = sensor.UniqueID
+			meta.Sensor = rawJSON(sensor.SensorMetadata)
+		default:
+			meta.Event = "changed"
+			meta.UniqueID = sensor.UniqueID
+			if !rawEqual(oldSensor.StateRaw, sensor.StateRaw) {
+				meta.State = sensor.StateRaw
+			}
+			if config := sensorConfig(sensor); !rawEqual(sensorConfig(oldSensor), config) {
+				meta.Config = config
+			}
+			if oldSensor.Name != sensor.Name {
+				meta.Name = sensor.Name
+			}
+			if len(meta.State) < 1 && len(meta.Config) < 1 && len(meta.Name) < 1 {
+				continue
+			}
+		}
+
+		update, err := newUpdate(meta)
+		if err != nil {
+			continue
+		}
+
+		event := Event{Update: update}
+		if hasSensor {
+			sensor := sensor
+			event.Sensor = &sensor
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// newUpdate builds a websocket update from the supplied metadata, decoding it the same way a received update is.
+func newUpdate(meta deconz.WebsocketUpdateMetadata) (*deconz.WebsocketUpdate, error) {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &deconz.WebsocketUpdate{}
+	if err := json.Unmarshal(b, update); err != nil {
+		return nil, err
+	}
+
+	return update, nil
+}
+
+// rawJSON encodes values which were decoded from JSON, and so can always be encoded again.
+func rawJSON(v interface{}) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// sensorConfig returns the complete config of a sensor, including the fields specific to its type.
+func sensorConfig(sensor deconz.Sensor) json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(rawJSON(sensor.SensorMetadata), &fields); err != nil {
+		return nil
+	}
+	return fields["config"]
+}
+
+// rawEqual compares two JSON objects regardless of key order or whitespace.
+func rawEqual(a, b json.RawMessage) bool {
+	var aVal, bVal interface{}
+	if err := json.Unmarshal(a, &aVal); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(b, &bVal); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(aVal, bVal)
+}
+
+// sortedIDs returns the union of the keys of both resource maps in a stable order.
+func sortedIDs(old, current interface{}) []string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, m := range []interface{}{old, current} {
+		for _, key := range reflect.ValueOf(m).MapKeys() {
+			id := key.String()
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
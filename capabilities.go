@@ -0,0 +1,297 @@
+package deconz
+
+import (
+	"fmt"
+
+	"github.com/rmrobinson/deconz-go/color"
+)
+
+// LightClass describes the kind of device exposed through the lights endpoint.
+type LightClass string
+
+const (
+	// LightClassLight is a bulb, strip or other lighting device
+	LightClassLight LightClass = "light"
+	// LightClassPlug is a plug-in unit or output which can only be switched on and off
+	LightClassPlug LightClass = "plug"
+	// LightClassWindowCovering is a blind, shade or curtain motor
+	LightClassWindowCovering LightClass = "windowcovering"
+	// LightClassWarningDevice is a siren or other warning device
+	LightClassWarningDevice LightClass = "warningdevice"
+	// LightClassDoorLock is a door lock
+	LightClassDoorLock LightClass = "doorlock"
+	// LightClassFan is a fan
+	LightClassFan LightClass = "fan"
+	// LightClassOther is any device whose type isn't known; it is assumed to support every field
+	LightClassOther LightClass = "other"
+)
+
+// ReportedLightCapabilities contains the capabilities reported by newer gateway versions.
+type ReportedLightCapabilities struct {
+	Alerts []string `json:"alerts,omitempty"`
+	Bri    *struct {
+		MinDimLevel float64 `json:"min_dim_level"`
+	} `json:"bri,omitempty"`
+	Color *struct {
+		CT *struct {
+			ComputesXY bool `json:"computes_xy"`
+			Max        int  `json:"max"`
+			Min        int  `json:"min"`
+		} `json:"ct,omitempty"`
+		Effects []string `json:"effects,omitempty"`
+		// GamutType is one of A, B, C or other
+		GamutType string   `json:"gamut_type,omitempty"`
+		Modes     []string `json:"modes,omitempty"`
+		XY        *struct {
+			Blue  []float64 `json:"blue"`
+			Green []float64 `json:"green"`
+			Red   []float64 `json:"red"`
+		} `json:"xy,omitempty"`
+	} `json:"color,omitempty"`
+}
+
+// LightCapabilities describes what a device on the lights endpoint is able to do.
+// It is derived from the light type, model and, where reported, the capabilities payload.
+type LightCapabilities struct {
+	Class LightClass
+
+	Dimmable bool
+
+	ColorTemperature bool
+	// CTMin and CTMax contain the supported colour temperature range, in mireds
+	CTMin int
+	CTMax int
+
+	Color bool
+	// Gamut contains the colours the light can reproduce; it is unrestricted if the gamut isn't known
+	Gamut color.Gamut
+
+	Alerts  []string
+	Effects []string
+}
+
+// typeCapabilities contains the capabilities implied by each light type.
+var typeCapabilities = map[string]LightCapabilities{
+	"On/Off light":               {Class: LightClassLight},
+	"On/Off plug-in unit":        {Class: LightClassPlug},
+	"On/Off output":              {Class: LightClassPlug},
+	"Smart plug":                 {Class: LightClassPlug},
+	"Dimmable light":             {Class: LightClassLight, Dimmable: true},
+	"Dimmable plug-in unit":      {Class: LightClassPlug, Dimmable: true},
+	"Dimmer switch":              {Class: LightClassLight, Dimmable: true},
+	"Color temperature light":    {Class: LightClassLight, Dimmable: true, ColorTemperature: true},
+	"Color light":                {Class: LightClassLight, Dimmable: true, Color: true},
+	"Color dimmable light":       {Class: LightClassLight, Dimmable: true, Color: true},
+	"Extended color light":       {Class: LightClassLight, Dimmable: true, ColorTemperature: true, Color: true},
+	"Window covering device":     {Class: LightClassWindowCovering},
+	"Window covering controller": {Class: LightClassWindowCovering},
+	"Warning device":             {Class: LightClassWarningDevice},
+	"Door Lock":                  {Class: LightClassDoorLock},
+	"Fan":                        {Class: LightClassFan},
+}
+
+// reportedGamuts maps the gamut types in the capabilities payload to the known gamuts.
+var reportedGamuts = map[string]color.Gamut{
+	"A": color.GamutA,
+	"B": color.GamutB,
+	"C": color.GamutC,
+}
+
+// Capabilities derives what the light is able to do.
+func (l *Light) Capabilities() LightCapabilities {
+	lc, ok := typeCapabilities[l.Type]
+	if !ok {
+		lc = LightCapabilities{Class: LightClassOther, Dimmable: true, ColorTemperature: true, Color: true}
+	}
+
+	if rc := l.ReportedCapabilities; rc != nil && rc.Color != nil && len(rc.Color.Modes) > 0 {
+		lc.ColorTemperature = false
+		lc.Color = false
+		for _, mode := range rc.Color.Modes {
+			switch mode {
+			case "ct":
+				lc.ColorTemperature = true
+			case "hs", "xy":
+				lc.Color = true
+			}
+		}
+	}
+
+	if lc.ColorTemperature {
+		lc.CTMin, lc.CTMax = l.CTMin, l.CTMax
+		if rc := l.ReportedCapabilities; rc != nil && rc.Color != nil && rc.Color.CT != nil {
+			lc.CTMin, lc.CTMax = rc.Color.CT.Min, rc.Color.CT.Max
+		}
+		// These are the limits of the Zigbee Light Link specification
+		if lc.CTMin < 1 {
+			lc.CTMin = 153
+		}
+		if lc.CTMax < 1 {
+			lc.CTMax = 500
+		}
+	}
+
+	if lc.Color {
+		lc.Gamut = l.Gamut()
+	}
+
+	if lc.Class == LightClassLight {
+		lc.Alerts = []string{"none", "select", "lselect"}
+		lc.Effects = []string{"none"}
+		if lc.Color {
+			lc.Effects = append(lc.Effects, "colorloop")
+		}
+	}
+	if rc := l.ReportedCapabilities; rc != nil {
+		if len(rc.Alerts) > 0 {
+			lc.Alerts = rc.Alerts
+		}
+		if rc.Color != nil && len(rc.Color.Effects) > 0 {
+			lc.Effects = rc.Color.Effects
+		}
+	}
+
+	return lc
+}
+
+func (l *Light) reportedGamut() color.Gamut {
+	rc := l.ReportedCapabilities
+	if rc == nil || rc.Color == nil {
+		return color.Gamut{}
+	}
+
+	if xy := rc.Color.XY; xy != nil && len(xy.Red) == 2 && len(xy.Green) == 2 && len(xy.Blue) == 2 {
+		return color.Gamut{
+			Red:   color.XY{X: xy.Red[0], Y: xy.Red[1]},
+			Green: color.XY{X: xy.Green[0], Y: xy.Green[1]},
+			Blue:  color.XY{X: xy.Blue[0], Y: xy.Blue[1]},
+		}
+	}
+	return reportedGamuts[rc.Color.GamutType]
+}
+
+// Validate checks that the device is able to honour every field of the request.
+// ErrUnsupportedByDevice is returned for fields the device doesn't support,
+// and ErrInvalidRequest for values outside of the range the device supports.
+func (lc LightCapabilities) Validate(req *SetLightStateRequest) error {
+	if !lc.Dimmable && (req.Brightness != nil || req.BrightnessIncrement != nil) {
+		return fmt.Errorf("%w: brightness", ErrUnsupportedByDevice)
+	}
+
+	if !lc.ColorTemperature && (req.CT != nil || req.CTIncrement != nil) {
+		return fmt.Errorf("%w: colour temperature", ErrUnsupportedByDevice)
+	}
+	if req.CT != nil && (*req.CT < lc.CTMin || *req.CT > lc.CTMax) {
+		return fmt.Errorf("%w: ct %d not in %d..%d", ErrInvalidRequest, *req.CT, lc.CTMin, lc.CTMax)
+	}
+
+	if !lc.Color && (req.XY != nil || req.XYIncrement != nil || req.Hue != nil || req.HueIncrement != nil ||
+		req.Saturation != nil || req.SaturationIncrement != nil || req.ColorLoopSpeed != nil) {
+		return fmt.Errorf("%w: colour", ErrUnsupportedByDevice)
+	}
+	if len(req.XY) == 2 && !lc.Gamut.Contains(color.XY{X: req.XY[0], Y: req.XY[1]}) {
+		return fmt.Errorf("%w: xy %v outside of the light gamut", ErrInvalidRequest, req.XY)
+	}
+
+	if !lc.windowCovering() && (req.Open != nil || req.Lift != nil || req.Tilt != nil || req.Stop != nil) {
+		return fmt.Errorf("%w: window covering", ErrUnsupportedByDevice)
+	}
+
+	if len(req.Alert) > 0 && !lc.supports(lc.Alerts, req.Alert) {
+		return fmt.Errorf("%w: alert %s", ErrUnsupportedByDevice, req.Alert)
+	}
+	if len(req.Effect) > 0 && !lc.supports(lc.Effects, req.Effect) {
+		return fmt.Errorf("%w: effect %s", ErrUnsupportedByDevice, req.Effect)
+	}
+
+	return nil
+}
+
+// Clamp returns a copy of the request which the device is able to honour.
+// Fields the device doesn't support are removed, the colour temperature is limited to the supported range
+// and colours are clipped to the light gamut.
+func (lc LightCapabilities) Clamp(req *SetLightStateRequest) *SetLightStateRequest {
+	ret := *req
+
+	if !lc.Dimmable {
+		ret.Brightness = nil
+		ret.BrightnessIncrement = nil
+	}
+
+	if !lc.ColorTemperature {
+		ret.CT = nil
+		ret.CTIncrement = nil
+	} else if ret.CT != nil {
+		ct := *ret.CT
+		if ct < lc.CTMin {
+			ct = lc.CTMin
+		} else if ct > lc.CTMax {
+			ct = lc.CTMax
+		}
+		ret.CT = &ct
+	}
+
+	if !lc.Color {
+		ret.XY = nil
+		ret.XYIncrement = nil
+		ret.Hue = nil
+		ret.HueIncrement = nil
+		ret.Saturation = nil
+		ret.SaturationIncrement = nil
+		ret.ColorLoopSpeed = nil
+	} else if len(ret.XY) == 2 {
+		xy := lc.Gamut.Clip(color.XY{X: ret.XY[0], Y: ret.XY[1]})
+		ret.XY = []float64{xy.X, xy.Y}
+	}
+
+	if !lc.windowCovering() {
+		ret.Open = nil
+		ret.Lift = nil
+		ret.Tilt = nil
+		ret.Stop = nil
+	}
+
+	if len(ret.Alert) > 0 && !lc.supports(lc.Alerts, ret.Alert) {
+		ret.Alert = ""
+	}
+	if len(ret.Effect) > 0 && !lc.supports(lc.Effects, ret.Effect) {
+		ret.Effect = ""
+	}
+
+	return &ret
+}
+
+// LightStateOption changes or checks a light state request before it is sent to the gateway.
+type LightStateOption func(*SetLightStateRequest) (*SetLightStateRequest, error)
+
+// RejectUnsupported fails the request, before it is sent, if the device can't honour it.
+func RejectUnsupported(lc LightCapabilities) LightStateOption {
+	return func(req *SetLightStateRequest) (*SetLightStateRequest, error) {
+		return req, lc.Validate(req)
+	}
+}
+
+// ClampToCapabilities changes the request, before it is sent, so the device can honour it.
+func ClampToCapabilities(lc LightCapabilities) LightStateOption {
+	return func(req *SetLightStateRequest) (*SetLightStateRequest, error) {
+		return lc.Clamp(req), nil
+	}
+}
+
+func (lc LightCapabilities) windowCovering() bool {
+	return lc.Class == LightClassWindowCovering || lc.Class == LightClassOther
+}
+
+// supports checks an alert or effect against the supported list; 'none' only stops the current one, so is always allowed.
+func (lc LightCapabilities) supports(list []string, v string) bool {
+	return v == "none" || lc.Class == LightClassOther || contains(list, v)
+}
+
+func contains(list []string, v string) bool {
+	for _, entry := range list {
+		if entry == v {
+			return true
+		}
+	}
+	return false
+}
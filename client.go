@@ -8,10 +8,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 var (
-	// ErrMalformedResponse is returned if the deconz response JSON isn't formatted as expected
+	// ErrMalformedResponse is returned, wrapped in a DecodeError, if the deconz response JSON isn't formatted as expected
 	ErrMalformedResponse = errors.New("malformed deconz response")
 )
 
@@ -19,6 +20,16 @@ var (
 type EmptyRequest struct {
 }
 
+// Bool returns a pointer to the supplied value, for use in requests which need to distinguish an unset field from false.
+func Bool(v bool) *bool {
+	return &v
+}
+
+// Int returns a pointer to the supplied value, for use in requests which need to distinguish an unset field from 0.
+func Int(v int) *int {
+	return &v
+}
+
 // Response is a generic response returned by the API
 type Response []ResponseEntry
 
@@ -40,6 +51,96 @@ func (re ResponseError) Error() string {
 	return fmt.Sprintf("%s: %d (%s)", re.Address, re.Type, re.Description)
 }
 
+// UpdateResult contains the outcome of a single field of a request which changes the gateway.
+type UpdateResult struct {
+	// Address contains the resource path of the field, i.e. /lights/1/state/bri
+	Address string
+	// Value contains the value the gateway applied. It is nil if the field was not applied.
+	Value interface{}
+	// Error contains the reason the field was not applied. It is nil if the field was applied.
+	Error *ResponseError
+}
+
+// UpdateResults contains the outcome of every field of a request which changes the gateway.
+type UpdateResults []UpdateResult
+
+// Succeeded returns the subset of results which were applied by the gateway.
+func (ur UpdateResults) Succeeded() UpdateResults {
+	var ret UpdateResults
+	for _, result := range ur {
+		if result.Error == nil {
+			ret = append(ret, result)
+		}
+	}
+	return ret
+}
+
+// Failed returns the subset of results which were rejected by the gateway.
+func (ur UpdateResults) Failed() UpdateResults {
+	var ret UpdateResults
+	for _, result := range ur {
+		if result.Error != nil {
+			ret = append(ret, result)
+		}
+	}
+	return ret
+}
+
+// UpdateError is returned when the gateway rejected one or more fields of a request.
+// Results contains every field of the request, including the ones which were applied.
+type UpdateError struct {
+	Results UpdateResults
+}
+
+// Error allows the update error to be returned as an Error compatible type.
+func (ue *UpdateError) Error() string {
+	var failed []string
+	for _, result := range ue.Results.Failed() {
+		failed = append(failed, result.Error.Error())
+	}
+	return strings.Join(failed, "; ")
+}
+
+// Unwrap returns the individual errors reported by the gateway so errors.Is and errors.As can inspect them.
+func (ue *UpdateError) Unwrap() []error {
+	var errs []error
+	for _, result := range ue.Results.Failed() {
+		errs = append(errs, *result.Error)
+	}
+	return errs
+}
+
+// newUpdateResults converts the raw gateway response into per-field results.
+// An UpdateError is returned if any of the entries were not successful.
+func newUpdateResults(resp Response) (UpdateResults, error) {
+	var results UpdateResults
+	failed := false
+
+	for _, entry := range resp {
+		if len(entry.Success) < 1 {
+			respErr := entry.Error
+			results = append(results, UpdateResult{
+				Address: respErr.Address,
+				Error:   &respErr,
+			})
+			failed = true
+			continue
+		}
+
+		for address, value := range entry.Success {
+			results = append(results, UpdateResult{
+				Address: address,
+				Value:   value,
+			})
+		}
+	}
+
+	if failed {
+		return results, &UpdateError{Results: results}
+	}
+	return results, nil
+}
+
 // Client represents a handle to the deconz API
 type Client struct {
 	httpClient *http.Client
@@ -63,7 +164,8 @@ func (c *Client) getURLBase() string {
 }
 
 func (c *Client) get(ctx context.Context, path string, respType interface{}) error {
-	r, err := http.NewRequest(http.MethodGet, c.getURLBase()+path, nil)
+	// The full state is retrieved from the API root, which doesn't take a trailing slash
+	r, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(c.getURLBase()+path, "/"), nil)
 	if err != nil {
 		return err
 	}
@@ -72,28 +174,31 @@ func (c *Client) get(ctx context.Context, path string, respType interface{}) err
 
 	resp, err := c.httpClient.Do(r)
 	if err != nil {
-		return err
+		return &TransportError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 200 {
-		return json.NewDecoder(resp.Body).Decode(respType)
+		if err := json.NewDecoder(resp.Body).Decode(respType); err != nil {
+			return &DecodeError{Err: err}
+		}
+		return nil
 	}
 
 	deconzResp := Response{}
 	err = json.NewDecoder(resp.Body).Decode(&deconzResp)
 	if err != nil {
-		return err
+		return &DecodeError{Err: err}
 	}
 
 	if len(deconzResp) < 1 {
-		return ErrMalformedResponse
+		return &DecodeError{Err: ErrMalformedResponse}
 	}
 
 	return deconzResp[0].Error
 }
 
-func (c *Client) post(ctx context.Context, path string, reqType interface{}) (*Response, error) {
+func (c *Client) post(ctx context.Context, path string, reqType interface{}) (UpdateResults, error) {
 	req, err := json.Marshal(reqType)
 	if err != nil {
 		return nil, err
@@ -108,63 +213,54 @@ func (c *Client) post(ctx context.Context, path string, reqType interface{}) (*R
 
 	resp, err := c.httpClient.Do(r)
 	if err != nil {
-		return nil, err
+		return nil, &TransportError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	deconzResp := Response{}
 	err = json.NewDecoder(resp.Body).Decode(&deconzResp)
 	if err != nil {
-		return nil, err
+		return nil, &DecodeError{Err: err}
 	}
 
 	if len(deconzResp) < 1 {
-		return nil, ErrMalformedResponse
-	}
-	for _, deconsRespEntry := range deconzResp {
-		if len(deconsRespEntry.Success) < 1 {
-			return nil, deconsRespEntry.Error
-		}
+		return nil, &DecodeError{Err: ErrMalformedResponse}
 	}
 
-	return &deconzResp, nil
+	return newUpdateResults(deconzResp)
 }
 
-func (c *Client) put(ctx context.Context, path string, reqType interface{}) error {
+func (c *Client) put(ctx context.Context, path string, reqType interface{}) (UpdateResults, error) {
 	req, err := json.Marshal(reqType)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	r, err := http.NewRequest(http.MethodPut, c.getURLBase()+path, bytes.NewBuffer(req))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	r = r.WithContext(ctx)
 
 	resp, err := c.httpClient.Do(r)
 	if err != nil {
-		return err
+		return nil, &TransportError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	deconzResp := Response{}
 	err = json.NewDecoder(resp.Body).Decode(&deconzResp)
 	if err != nil {
-		return err
+		return nil, &DecodeError{Err: err}
 	}
 
-	if len(deconzResp) < 1 {
-		return ErrMalformedResponse
-	}
-	for _, deconsRespEntry := range deconzResp {
-		if len(deconsRespEntry.Success) < 1 {
-			return deconsRespEntry.Error
-		}
+	// An empty list is valid, as nothing is changed by an empty request; null is not
+	if deconzResp == nil {
+		return nil, &DecodeError{Err: ErrMalformedResponse}
 	}
 
-	return nil
+	return newUpdateResults(deconzResp)
 }
 
 func (c *Client) delete(ctx context.Context, path string) error {
@@ -177,18 +273,18 @@ func (c *Client) delete(ctx context.Context, path string) error {
 
 	resp, err := c.httpClient.Do(r)
 	if err != nil {
-		return err
+		return &TransportError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	deconzResp := Response{}
 	err = json.NewDecoder(resp.Body).Decode(&deconzResp)
 	if err != nil {
-		return err
+		return &DecodeError{Err: err}
 	}
 
 	if len(deconzResp) < 1 {
-		return ErrMalformedResponse
+		return &DecodeError{Err: ErrMalformedResponse}
 	}
 	for _, deconsRespEntry := range deconzResp {
 		if len(deconsRespEntry.Success) < 1 {
@@ -0,0 +1,229 @@
+package deconz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The model, manufacturer and software version used by the CLIP sensor constructors.
+// deCONZ requires them when a sensor is created, but doesn't use them for CLIP sensors.
+const (
+	CLIPSensorManufacturer = "deconz-go"
+	CLIPSensorSWVersion    = "1.0"
+)
+
+// CreateSensor creates a new CLIP sensor on the gateway. The new ID is returned on success.
+// The constructors such as NewCLIPGenericFlag fill in the fields required for each CLIP type.
+func (c *Client) CreateSensor(ctx context.Context, req *CreateSensorRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+
+	results, err := c.post(ctx, "sensors", req)
+	if err != nil {
+		return "", err
+	}
+
+	if len(results) < 1 {
+		return "", errors.New("new sensor missing success entry")
+	}
+	if results[0].Address == "id" {
+		if strID, ok := results[0].Value.(string); ok {
+			return strID, nil
+		}
+		return "", errors.New("new sensor id not string")
+	}
+
+	return "", errors.New("new sensor missing id entry")
+}
+
+// CreateSensorRequest is used to create a new CLIP sensor.
+// The unique ID identifies the sensor to the gateway; creating a sensor with the unique ID of an existing one fails.
+type CreateSensorRequest struct {
+	Name             string                 `json:"name"`
+	Type             string                 `json:"type"`
+	ModelID          string                 `json:"modelid"`
+	ManufacturerName string                 `json:"manufacturername"`
+	SWVersion        string                 `json:"swversion"`
+	UniqueID         string                 `json:"uniqueid"`
+	State            *SetSensorStateRequest `json:"state,omitempty"`
+	Config           *CreateSensorConfig    `json:"config,omitempty"`
+}
+
+// CreateSensorConfig contains the initial config of a CLIP sensor.
+type CreateSensorConfig struct {
+	On        *bool `json:"on,omitempty"`
+	Reachable *bool `json:"reachable,omitempty"`
+	Battery   *int  `json:"battery,omitempty"`
+	// URL is an optional address associated with the sensor
+	URL string `json:"url,omitempty"`
+}
+
+// Validate checks that the fields required by the gateway are set.
+// It is called by CreateSensor before the request is sent.
+func (r *CreateSensorRequest) Validate() error {
+	if r == nil {
+		return fmt.Errorf("%w: no sensor specified", ErrInvalidRequest)
+	}
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"name", r.Name},
+		{"type", r.Type},
+		{"modelid", r.ModelID},
+		{"manufacturername", r.ManufacturerName},
+		{"swversion", r.SWVersion},
+		{"uniqueid", r.UniqueID},
+	}
+	for _, field := range required {
+		if len(field.value) < 1 {
+			return fmt.Errorf("%w: %s is required", ErrInvalidRequest, field.name)
+		}
+	}
+	if !strings.HasPrefix(r.Type, "CLIP") {
+		return fmt.Errorf("%w: only CLIP sensors can be created, not %s", ErrInvalidRequest, r.Type)
+	}
+	if r.Config != nil && r.Config.Battery != nil && (*r.Config.Battery < 0 || *r.Config.Battery > 100) {
+		return fmt.Errorf("%w: battery %d not in 0..100", ErrInvalidRequest, *r.Config.Battery)
+	}
+	return nil
+}
+
+// newCLIPSensor creates a request for a CLIP sensor with the supplied initial state.
+// The model ID defaults to the type, which can be replaced to describe what the sensor is used for.
+func newCLIPSensor(sensorType, name, uniqueID string, state *SetSensorStateRequest) *CreateSensorRequest {
+	return &CreateSensorRequest{
+		Name:             name,
+		Type:             sensorType,
+		ModelID:          sensorType,
+		ManufacturerName: CLIPSensorManufacturer,
+		SWVersion:        CLIPSensorSWVersion,
+		UniqueID:         uniqueID,
+		State:            state,
+	}
+}
+
+// NewCLIPSwitch creates a request for a switch whose button events are set through SetSensorState.
+func NewCLIPSwitch(name, uniqueID string) *CreateSensorRequest {
+	return newCLIPSensor("CLIPSwitch", name, uniqueID, NewSensorState().WithButtonEvent(0))
+}
+
+// NewCLIPOpenClose creates a request for an open/close sensor in the supplied initial state.
+func NewCLIPOpenClose(name, uniqueID string, open bool) *CreateSensorRequest {
+	return newCLIPSensor("CLIPOpenClose", name, uniqueID, NewSensorState().WithOpen(open))
+}
+
+// NewCLIPPresence creates a request for a presence sensor in the supplied initial state.
+func NewCLIPPresence(name, uniqueID string, presence bool) *CreateSensorRequest {
+	return newCLIPSensor("CLIPPresence", name, uniqueID, NewSensorState().WithPresence(presence))
+}
+
+// NewCLIPTemperature creates a request for a temperature sensor with the supplied initial temperature, in hundredths of a degree Celsius.
+func NewCLIPTemperature(name, uniqueID string, temperature int) *CreateSensorRequest {
+	return newCLIPSensor("CLIPTemperature", name, uniqueID, NewSensorState().WithTemperature(temperature))
+}
+
+// NewCLIPHumidity creates a request for a humidity sensor with the supplied initial humidity, in hundredths of a percent.
+func NewCLIPHumidity(name, uniqueID string, humidity int) *CreateSensorRequest {
+	return newCLIPSensor("CLIPHumidity", name, uniqueID, NewSensorState().WithHumidity(humidity))
+}
+
+// NewCLIPGenericFlag creates a request for a flag in the supplied initial state.
+// Flags are typically used in rules as software switches, such as an away mode.
+func NewCLIPGenericFlag(name, uniqueID string, flag bool) *CreateSensorRequest {
+	return newCLIPSensor("CLIPGenericFlag", name, uniqueID, NewSensorState().WithFlag(flag))
+}
+
+// NewCLIPGenericStatus creates a request for a status variable with the supplied initial value.
+// Statuses are typically used in rules to track which of several states something is in.
+func NewCLIPGenericStatus(name, uniqueID string, status int) *CreateSensorRequest {
+	return newCLIPSensor("CLIPGenericStatus", name, uniqueID, NewSensorState().WithStatus(status))
+}
+
+// WithModelID replaces the model ID of the sensor.
+func (r *CreateSensorRequest) WithModelID(modelID string) *CreateSensorRequest {
+	r.ModelID = modelID
+	return r
+}
+
+// WithConfig sets the initial config of the sensor.
+func (r *CreateSensorRequest) WithConfig(config *CreateSensorConfig) *CreateSensorRequest {
+	r.Config = config
+	return r
+}
+
+// SetSensorStateRequest contains the properties of a CLIP sensor which can be set.
+// Fields which are nil are not sent, so only the specified properties change;
+// NewSensorState and the With methods can be used to build a request without handling the pointers directly.
+type SetSensorStateRequest struct {
+	// ButtonEvent is settable for CLIPSwitch type
+	ButtonEvent *int `json:"buttonevent,omitempty"`
+
+	// Open is settable for CLIPOpenClose
+	Open *bool `json:"open,omitempty"`
+
+	// Presence is settable for CLIPPresence
+	Presence *bool `json:"presence,omitempty"`
+
+	// Temperature is settable for CLIPTemperature
+	Temperature *int `json:"temperature,omitempty"`
+
+	// Flag is settable for CLIPGenericFlag
+	Flag *bool `json:"flag,omitempty"`
+
+	// Status is settable for CLIPGenericStatus
+	Status *int `json:"status,omitempty"`
+
+	// Humidity is settable for CLIPHumidity
+	Humidity *int `json:"humidity,omitempty"`
+}
+
+// NewSensorState creates an empty sensor state request; nothing is changed until one of the With methods is called.
+func NewSensorState() *SetSensorStateRequest {
+	return &SetSensorStateRequest{}
+}
+
+// WithButtonEvent sets the button event of a CLIPSwitch.
+func (r *SetSensorStateRequest) WithButtonEvent(event int) *SetSensorStateRequest {
+	r.ButtonEvent = &event
+	return r
+}
+
+// WithOpen sets whether a CLIPOpenClose is open.
+func (r *SetSensorStateRequest) WithOpen(open bool) *SetSensorStateRequest {
+	r.Open = &open
+	return r
+}
+
+// WithPresence sets whether a CLIPPresence detects presence.
+func (r *SetSensorStateRequest) WithPresence(presence bool) *SetSensorStateRequest {
+	r.Presence = &presence
+	return r
+}
+
+// WithTemperature sets the temperature of a CLIPTemperature, in hundredths of a degree Celsius.
+func (r *SetSensorStateRequest) WithTemperature(temperature int) *SetSensorStateRequest {
+	r.Temperature = &temperature
+	return r
+}
+
+// WithFlag sets the flag of a CLIPGenericFlag.
+func (r *SetSensorStateRequest) WithFlag(flag bool) *SetSensorStateRequest {
+	r.Flag = &flag
+	return r
+}
+
+// WithStatus sets the status of a CLIPGenericStatus.
+func (r *SetSensorStateRequest) WithStatus(status int) *SetSensorStateRequest {
+	r.Status = &status
+	return r
+}
+
+// WithHumidity sets the humidity of a CLIPHumidity, in hundredths of a percent.
+func (r *SetSensorStateRequest) WithHumidity(humidity int) *SetSensorStateRequest {
+	r.Humidity = &humidity
+	return r
+}
@@ -0,0 +1,250 @@
+// Package color converts between the colour representations used by deCONZ lights
+// (CIE xy with brightness, hue/saturation and colour temperature in mireds) and
+// the ones used by people and displays (sRGB, hex and HSV).
+package color
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrInvalidHex is returned if a hex colour string can't be parsed.
+var ErrInvalidHex = errors.New("invalid hex colour")
+
+// RGB contains a colour in the sRGB colour space.
+type RGB struct {
+	R uint8
+	G uint8
+	B uint8
+}
+
+// XY contains a chromaticity in the CIE 1931 colour space.
+type XY struct {
+	X float64
+	Y float64
+}
+
+// HSV contains a colour as hue (0 to 360 degrees), saturation (0 to 1) and value (0 to 1).
+type HSV struct {
+	H float64
+	S float64
+	V float64
+}
+
+// ParseHex parses a colour specified as #rrggbb, rrggbb, #rgb or rgb.
+func ParseHex(s string) (RGB, error) {
+	digits := strings.TrimPrefix(s, "#")
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	if len(digits) != 6 {
+		return RGB{}, fmt.Errorf("%w: %s", ErrInvalidHex, s)
+	}
+
+	b, err := hex.DecodeString(digits)
+	if err != nil {
+		return RGB{}, fmt.Errorf("%w: %s", ErrInvalidHex, s)
+	}
+	return RGB{R: b[0], G: b[1], B: b[2]}, nil
+}
+
+// Hex returns the colour formatted as #rrggbb.
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// HSV converts the colour to hue, saturation and value.
+func (c RGB) HSV() HSV {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	delta := max - min
+
+	hsv := HSV{V: max}
+	if max > 0 {
+		hsv.S = delta / max
+	}
+	if delta == 0 {
+		return hsv
+	}
+
+	switch max {
+	case r:
+		hsv.H = 60 * math.Mod((g-b)/delta, 6)
+	case g:
+		hsv.H = 60 * ((b-r)/delta + 2)
+	default:
+		hsv.H = 60 * ((r-g)/delta + 4)
+	}
+	if hsv.H < 0 {
+		hsv.H += 360
+	}
+	return hsv
+}
+
+// RGB converts the colour to sRGB.
+func (hsv HSV) RGB() RGB {
+	h := math.Mod(hsv.H, 360)
+	if h < 0 {
+		h += 360
+	}
+	c := hsv.V * hsv.S
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := hsv.V - c
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return RGB{R: toByte(r + m), G: toByte(g + m), B: toByte(b + m)}
+}
+
+// HueSat converts the colour to the hue (0 to 65535), saturation (0 to 254) and brightness (0 to 254) used by the gateway.
+func (c RGB) HueSat() (int, int, int) {
+	hsv := c.HSV()
+	return int(math.Round(hsv.H / 360 * 65535)), int(math.Round(hsv.S * 254)), int(math.Round(hsv.V * 254))
+}
+
+// FromHueSat converts the hue (0 to 65535), saturation (0 to 254) and brightness (0 to 254) used by the gateway to sRGB.
+func FromHueSat(hue, sat, bri int) RGB {
+	return HSV{
+		H: float64(hue) / 65535 * 360,
+		S: clamp(float64(sat)/254, 0, 1),
+		V: clamp(float64(bri)/254, 0, 1),
+	}.RGB()
+}
+
+// XY converts the colour to CIE xy, clipped to the supplied gamut, and the brightness (0 to 254) used by the gateway.
+// The brightness is taken from the brightest channel, so saturated colours such as pure blue aren't dimmed.
+func (c RGB) XY(gamut Gamut) (XY, int) {
+	r := toLinear(float64(c.R) / 255)
+	g := toLinear(float64(c.G) / 255)
+	b := toLinear(float64(c.B) / 255)
+
+	// Wide gamut RGB D65 conversion, as used by the Zigbee Light Link lights
+	x := r*0.664511 + g*0.154324 + b*0.162028
+	y := r*0.283881 + g*0.668433 + b*0.047685
+	z := r*0.000088 + g*0.072310 + b*0.986039
+
+	sum := x + y + z
+	if sum == 0 {
+		return gamut.Clip(whitePoint), 0
+	}
+
+	bri := math.Max(float64(c.R), math.Max(float64(c.G), float64(c.B))) / 255
+	return gamut.Clip(XY{X: x / sum, Y: y / sum}), int(math.Round(bri * 254))
+}
+
+// FromXY converts a CIE xy colour, clipped to the supplied gamut, and a brightness (0 to 254) to sRGB.
+func FromXY(xy XY, bri int, gamut Gamut) RGB {
+	xy = gamut.Clip(xy)
+	if xy.Y <= 0 {
+		return RGB{}
+	}
+
+	x := xy.X / xy.Y
+	z := (1 - xy.X - xy.Y) / xy.Y
+
+	r := x*1.656492 - 0.354851 - z*0.255038
+	g := -x*0.707196 + 1.655397 + z*0.036152
+	b := x*0.051713 - 0.121364 + z*1.011530
+
+	// Scale the colour so the brightest channel is fully on; the brightness is applied afterwards
+	r, g, b = math.Max(r, 0), math.Max(g, 0), math.Max(b, 0)
+	if max := math.Max(r, math.Max(g, b)); max > 0 {
+		r, g, b = r/max, g/max, b/max
+	}
+
+	scale := clamp(float64(bri)/254, 0, 1)
+	return RGB{
+		R: toByte(fromLinear(r) * scale),
+		G: toByte(fromLinear(g) * scale),
+		B: toByte(fromLinear(b) * scale),
+	}
+}
+
+// KelvinToMired converts a colour temperature in Kelvin to the mireds used by the gateway.
+func KelvinToMired(kelvin int) int {
+	if kelvin <= 0 {
+		return 0
+	}
+	return int(math.Round(1000000 / float64(kelvin)))
+}
+
+// MiredToKelvin converts a colour temperature in mireds, as used by the gateway, to Kelvin.
+func MiredToKelvin(mired int) int {
+	if mired <= 0 {
+		return 0
+	}
+	return int(math.Round(1000000 / float64(mired)))
+}
+
+// KelvinToXY converts a colour temperature on the Planckian locus to CIE xy.
+// Temperatures are limited to the 1667K to 25000K range of the approximation.
+func KelvinToXY(kelvin int) XY {
+	t := clamp(float64(kelvin), 1667, 25000)
+
+	var x float64
+	if t <= 4000 {
+		x = -0.2661239e9/(t*t*t) - 0.2343589e6/(t*t) + 0.8776956e3/t + 0.179910
+	} else {
+		x = -3.0258469e9/(t*t*t) + 2.1070379e6/(t*t) + 0.2226347e3/t + 0.240390
+	}
+
+	var y float64
+	switch {
+	case t <= 2222:
+		y = -1.1063814*x*x*x - 1.34811020*x*x + 2.18555832*x - 0.20219683
+	case t <= 4000:
+		y = -0.9549476*x*x*x - 1.37418593*x*x + 2.09137015*x - 0.16748867
+	default:
+		y = 3.0817580*x*x*x - 5.87338670*x*x + 3.75112997*x - 0.37001483
+	}
+
+	return XY{X: x, Y: y}
+}
+
+// FromMired converts a colour temperature in mireds and a brightness (0 to 254) to sRGB.
+func FromMired(mired, bri int) RGB {
+	return FromXY(KelvinToXY(MiredToKelvin(mired)), bri, Gamut{})
+}
+
+// whitePoint is the D65 white point, used for black and other colours without a chromaticity.
+var whitePoint = XY{X: 0.3127, Y: 0.3290}
+
+func toLinear(v float64) float64 {
+	if v > 0.04045 {
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return v / 12.92
+}
+
+func fromLinear(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func toByte(v float64) uint8 {
+	return uint8(math.Round(clamp(v, 0, 1) * 255))
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
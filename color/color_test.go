@@ -0,0 +1,199 @@
+package color
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		in   string
+		want RGB
+	}{
+		{"#ff0000", RGB{255, 0, 0}},
+		{"00ff00", RGB{0, 255, 0}},
+		{"#0000FF", RGB{0, 0, 255}},
+		{"#abc", RGB{0xaa, 0xbb, 0xcc}},
+		{"123", RGB{0x11, 0x22, 0x33}},
+		{"#123456", RGB{0x12, 0x34, 0x56}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseHex(tt.in)
+		if err != nil {
+			t.Errorf("ParseHex(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseHex(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseHexInvalid(t *testing.T) {
+	for _, in := range []string{"", "#", "12 456", " 1 2 3", "#12345", "#1234567", "gggggg", "#12345g", "+12345", "1 2"} {
+		if got, err := ParseHex(in); !errors.Is(err, ErrInvalidHex) {
+			t.Errorf("ParseHex(%q) = %v, %v, want ErrInvalidHex", in, got, err)
+		}
+	}
+}
+
+func TestHex(t *testing.T) {
+	tests := []struct {
+		in   RGB
+		want string
+	}{
+		{RGB{}, "#000000"},
+		{RGB{255, 255, 255}, "#ffffff"},
+		{RGB{0x12, 0xab, 0x05}, "#12ab05"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.in.Hex(); got != tt.want {
+			t.Errorf("%v.Hex() = %q, want %q", tt.in, got, tt.want)
+		}
+		if back, err := ParseHex(tt.want); err != nil || back != tt.in {
+			t.Errorf("ParseHex(%q) = %v, %v, want %v", tt.want, back, err, tt.in)
+		}
+	}
+}
+
+func TestHSV(t *testing.T) {
+	tests := []struct {
+		in   RGB
+		want HSV
+	}{
+		{RGB{}, HSV{0, 0, 0}},
+		{RGB{255, 255, 255}, HSV{0, 0, 1}},
+		{RGB{255, 0, 0}, HSV{0, 1, 1}},
+		{RGB{0, 255, 0}, HSV{120, 1, 1}},
+		{RGB{0, 0, 255}, HSV{240, 1, 1}},
+		{RGB{255, 0, 255}, HSV{300, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		got := tt.in.HSV()
+		if !near(got.H, tt.want.H, 0.01) || !near(got.S, tt.want.S, 0.01) || !near(got.V, tt.want.V, 0.01) {
+			t.Errorf("%v.HSV() = %v, want %v", tt.in, got, tt.want)
+		}
+		if back := got.RGB(); back != tt.in {
+			t.Errorf("%v.RGB() = %v, want %v", got, back, tt.in)
+		}
+	}
+}
+
+func TestHueSat(t *testing.T) {
+	tests := []struct {
+		in            RGB
+		hue, sat, bri int
+	}{
+		{RGB{255, 0, 0}, 0, 254, 254},
+		{RGB{0, 0, 255}, 43690, 254, 254},
+		{RGB{255, 255, 255}, 0, 0, 254},
+	}
+
+	for _, tt := range tests {
+		hue, sat, bri := tt.in.HueSat()
+		if hue != tt.hue || sat != tt.sat || bri != tt.bri {
+			t.Errorf("%v.HueSat() = %d, %d, %d, want %d, %d, %d", tt.in, hue, sat, bri, tt.hue, tt.sat, tt.bri)
+		}
+		if back := FromHueSat(hue, sat, bri); back != tt.in {
+			t.Errorf("FromHueSat(%d, %d, %d) = %v, want %v", hue, sat, bri, back, tt.in)
+		}
+	}
+}
+
+func TestXY(t *testing.T) {
+	// The wide gamut conversion places white slightly towards red of D65
+	xy, bri := RGB{255, 255, 255}.XY(Gamut{})
+	if !near(xy.X, 0.3227, 0.001) || !near(xy.Y, 0.3290, 0.001) || bri != 254 {
+		t.Errorf("white XY = %v, %d, want about {0.3227 0.3290}, 254", xy, bri)
+	}
+
+	xy, bri = RGB{}.XY(GamutC)
+	if xy != whitePoint || bri != 0 {
+		t.Errorf("black XY = %v, %d, want %v, 0", xy, bri, whitePoint)
+	}
+
+	// Pure blue is outside gamut B, so is clipped onto its edge at full brightness
+	xy, bri = RGB{0, 0, 255}.XY(GamutB)
+	if !GamutB.Contains(xy) || bri != 254 {
+		t.Errorf("blue XY in gamut B = %v, %d, want a colour in the gamut at 254", xy, bri)
+	}
+}
+
+func TestXYRoundTrip(t *testing.T) {
+	for _, in := range []RGB{{255, 0, 0}, {0, 255, 0}, {0, 0, 255}, {255, 255, 255}, {255, 128, 0}, {128, 0, 255}} {
+		xy, bri := in.XY(Gamut{})
+		got := FromXY(xy, bri, Gamut{})
+		if !nearByte(got.R, in.R, 3) || !nearByte(got.G, in.G, 3) || !nearByte(got.B, in.B, 3) {
+			t.Errorf("FromXY(%v.XY()) = %v, want %v", in, got, in)
+		}
+	}
+}
+
+func TestFromXYBrightness(t *testing.T) {
+	if got := FromXY(whitePoint, 0, Gamut{}); got != (RGB{}) {
+		t.Errorf("FromXY at brightness 0 = %v, want black", got)
+	}
+	if got := FromXY(XY{X: 0.3, Y: 0}, 254, Gamut{}); got != (RGB{}) {
+		t.Errorf("FromXY with y of 0 = %v, want black", got)
+	}
+}
+
+func TestMired(t *testing.T) {
+	tests := []struct {
+		kelvin, mired int
+	}{
+		{2700, 370},
+		{4000, 250},
+		{6500, 154},
+	}
+
+	for _, tt := range tests {
+		if got := KelvinToMired(tt.kelvin); got != tt.mired {
+			t.Errorf("KelvinToMired(%d) = %d, want %d", tt.kelvin, got, tt.mired)
+		}
+	}
+	if got := MiredToKelvin(250); got != 4000 {
+		t.Errorf("MiredToKelvin(250) = %d, want 4000", got)
+	}
+	if got := KelvinToMired(0); got != 0 {
+		t.Errorf("KelvinToMired(0) = %d, want 0", got)
+	}
+	if got := MiredToKelvin(-1); got != 0 {
+		t.Errorf("MiredToKelvin(-1) = %d, want 0", got)
+	}
+}
+
+func TestKelvinToXY(t *testing.T) {
+	tests := []struct {
+		kelvin int
+		want   XY
+	}{
+		{2700, XY{0.4599, 0.4106}},
+		{4000, XY{0.3805, 0.3768}},
+		{6500, XY{0.3135, 0.3236}},
+	}
+
+	for _, tt := range tests {
+		got := KelvinToXY(tt.kelvin)
+		if !near(got.X, tt.want.X, 0.002) || !near(got.Y, tt.want.Y, 0.002) {
+			t.Errorf("KelvinToXY(%d) = %v, want %v", tt.kelvin, got, tt.want)
+		}
+	}
+
+	if got, want := KelvinToXY(100), KelvinToXY(1667); got != want {
+		t.Errorf("KelvinToXY(100) = %v, want it limited to %v", got, want)
+	}
+}
+
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func nearByte(got, want uint8, tolerance int) bool {
+	d := int(got) - int(want)
+	return d >= -tolerance && d <= tolerance
+}
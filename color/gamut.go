@@ -0,0 +1,127 @@
+package color
+
+import "sync"
+
+// Gamut contains the corners of the triangle of colours a light can reproduce.
+// The zero value places no restriction on the colours.
+type Gamut struct {
+	Red   XY
+	Green XY
+	Blue  XY
+}
+
+var (
+	// GamutA is used by the first generation LivingColors and LightStrips
+	GamutA = Gamut{Red: XY{0.704, 0.296}, Green: XY{0.2151, 0.7106}, Blue: XY{0.138, 0.08}}
+	// GamutB is used by the first generation Hue bulbs
+	GamutB = Gamut{Red: XY{0.675, 0.322}, Green: XY{0.409, 0.518}, Blue: XY{0.167, 0.04}}
+	// GamutC is used by the later generation Hue bulbs and LightStrips
+	GamutC = Gamut{Red: XY{0.6915, 0.3038}, Green: XY{0.17, 0.7}, Blue: XY{0.1532, 0.0475}}
+)
+
+var (
+	modelGamutsMu sync.RWMutex
+	// modelGamuts contains the gamut of the known colour light models.
+	modelGamuts = map[string]Gamut{
+		"LLC001": GamutA,
+		"LLC005": GamutA,
+		"LLC006": GamutA,
+		"LLC007": GamutA,
+		"LLC010": GamutA,
+		"LLC011": GamutA,
+		"LLC012": GamutA,
+		"LLC013": GamutA,
+		"LLC014": GamutA,
+		"LST001": GamutA,
+
+		"LCT001": GamutB,
+		"LCT002": GamutB,
+		"LCT003": GamutB,
+		"LCT007": GamutB,
+		"LLM001": GamutB,
+
+		"LCT010": GamutC,
+		"LCT011": GamutC,
+		"LCT012": GamutC,
+		"LCT014": GamutC,
+		"LCT015": GamutC,
+		"LCT016": GamutC,
+		"LCT024": GamutC,
+		"LLC020": GamutC,
+		"LST002": GamutC,
+		"LCA001": GamutC,
+		"LCA002": GamutC,
+		"LCA003": GamutC,
+		"LCG002": GamutC,
+	}
+)
+
+// GamutForModel returns the gamut of the specified light model ID.
+// The returned bool is false, and the gamut unrestricted, if the model isn't known.
+func GamutForModel(modelID string) (Gamut, bool) {
+	modelGamutsMu.RLock()
+	defer modelGamutsMu.RUnlock()
+
+	gamut, ok := modelGamuts[modelID]
+	return gamut, ok
+}
+
+// RegisterModelGamut records the gamut of a light model, replacing any existing gamut.
+func RegisterModelGamut(modelID string, gamut Gamut) {
+	modelGamutsMu.Lock()
+	defer modelGamutsMu.Unlock()
+
+	modelGamuts[modelID] = gamut
+}
+
+// IsZero returns true if the gamut places no restriction on the colours.
+func (g Gamut) IsZero() bool {
+	return g == Gamut{}
+}
+
+// Contains returns true if the colour can be reproduced within the gamut.
+func (g Gamut) Contains(xy XY) bool {
+	if g.IsZero() {
+		return true
+	}
+
+	d1 := cross(xy, g.Red, g.Green)
+	d2 := cross(xy, g.Green, g.Blue)
+	d3 := cross(xy, g.Blue, g.Red)
+
+	hasNeg := d1 < 0 || d2 < 0 || d3 < 0
+	hasPos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(hasNeg && hasPos)
+}
+
+// Clip returns the closest colour to the supplied one which can be reproduced within the gamut.
+func (g Gamut) Clip(xy XY) XY {
+	if g.Contains(xy) {
+		return xy
+	}
+
+	best := closestOnLine(g.Red, g.Green, xy)
+	bestDist := distanceSquared(best, xy)
+	for _, candidate := range []XY{closestOnLine(g.Green, g.Blue, xy), closestOnLine(g.Blue, g.Red, xy)} {
+		if dist := distanceSquared(candidate, xy); dist < bestDist {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best
+}
+
+func cross(p, a, b XY) float64 {
+	return (p.X-b.X)*(a.Y-b.Y) - (a.X-b.X)*(p.Y-b.Y)
+}
+
+func closestOnLine(a, b, p XY) XY {
+	ap := XY{X: p.X - a.X, Y: p.Y - a.Y}
+	ab := XY{X: b.X - a.X, Y: b.Y - a.Y}
+	t := clamp((ap.X*ab.X+ap.Y*ab.Y)/(ab.X*ab.X+ab.Y*ab.Y), 0, 1)
+	return XY{X: a.X + ab.X*t, Y: a.Y + ab.Y*t}
+}
+
+func distanceSquared(a, b XY) float64 {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	return dx*dx + dy*dy
+}
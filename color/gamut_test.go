@@ -0,0 +1,71 @@
+package color
+
+import "testing"
+
+func TestGamutContains(t *testing.T) {
+	tests := []struct {
+		gamut Gamut
+		xy    XY
+		want  bool
+	}{
+		{GamutC, GamutC.Red, true},
+		{GamutC, GamutC.Green, true},
+		{GamutC, GamutC.Blue, true},
+		{GamutC, whitePoint, true},
+		{GamutB, XY{0.17, 0.7}, false},
+		{GamutA, XY{0.8, 0.1}, false},
+		{GamutA, XY{0, 0}, false},
+		{Gamut{}, XY{0.8, 0.8}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.gamut.Contains(tt.xy); got != tt.want {
+			t.Errorf("%v.Contains(%v) = %t, want %t", tt.gamut, tt.xy, got, tt.want)
+		}
+	}
+}
+
+func TestGamutClip(t *testing.T) {
+	tests := []struct {
+		gamut Gamut
+		xy    XY
+		want  XY
+	}{
+		// Colours already in the gamut are unchanged
+		{GamutC, whitePoint, whitePoint},
+		{Gamut{}, XY{0.9, 0.9}, XY{0.9, 0.9}},
+		// Colours beyond a corner clip to the corner
+		{GamutB, XY{0.8, 0.3}, GamutB.Red},
+		{GamutB, XY{0.1, 0.0}, GamutB.Blue},
+		// Colours beyond an edge clip to the closest point on it
+		{GamutB, XY{0.5717, 0.4603}, XY{0.542, 0.42}},
+		{GamutB, XY{0.3, 0.9}, GamutB.Green},
+	}
+
+	for _, tt := range tests {
+		got := tt.gamut.Clip(tt.xy)
+		if !near(got.X, tt.want.X, 0.001) || !near(got.Y, tt.want.Y, 0.001) {
+			t.Errorf("%v.Clip(%v) = %v, want %v", tt.gamut, tt.xy, got, tt.want)
+		}
+		// Moving slightly towards the centre must stay in the gamut, as the clipped colour is on or inside its edge
+		centre := XY{X: (tt.gamut.Red.X + tt.gamut.Green.X + tt.gamut.Blue.X) / 3, Y: (tt.gamut.Red.Y + tt.gamut.Green.Y + tt.gamut.Blue.Y) / 3}
+		inward := XY{X: got.X + (centre.X-got.X)*0.01, Y: got.Y + (centre.Y-got.Y)*0.01}
+		if !tt.gamut.Contains(inward) {
+			t.Errorf("%v.Clip(%v) = %v, which is outside the gamut", tt.gamut, tt.xy, got)
+		}
+	}
+}
+
+func TestGamutForModel(t *testing.T) {
+	if gamut, ok := GamutForModel("LCT015"); !ok || gamut != GamutC {
+		t.Errorf("GamutForModel(LCT015) = %v, %t, want gamut C", gamut, ok)
+	}
+	if gamut, ok := GamutForModel("unknown-model"); ok || !gamut.IsZero() {
+		t.Errorf("GamutForModel(unknown-model) = %v, %t, want an unrestricted gamut", gamut, ok)
+	}
+
+	RegisterModelGamut("test-model", GamutA)
+	if gamut, ok := GamutForModel("test-model"); !ok || gamut != GamutA {
+		t.Errorf("GamutForModel(test-model) = %v, %t, want the registered gamut A", gamut, ok)
+	}
+}
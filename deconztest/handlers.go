@@ -0,0 +1,1236 @@
+package deconztest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func (s *Server) serveREST(w http.ResponseWriter, r *http.Request) {
+	parts := splitPath(r.URL.Path)
+	if len(parts) < 1 || parts[0] != "api" {
+		s.writeError(w, 3, r.URL.Path, fmt.Sprintf("resource, %s, not available", r.URL.Path))
+		return
+	}
+
+	body := object{}
+	if r.Method == http.MethodPut || r.Method == http.MethodPost {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.writeError(w, 2, "/", "body contains invalid JSON")
+			return
+		}
+		if len(strings.TrimSpace(string(b))) > 0 {
+			if err := json.Unmarshal(b, &body); err != nil {
+				s.writeError(w, 2, "/", "body contains invalid JSON")
+				return
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodPost {
+			s.methodNotAvailable(w, r.Method, "/")
+			return
+		}
+		s.createAPIKey(w, body)
+		return
+	}
+
+	path := parts[2:]
+	address := "/" + strings.Join(path, "/")
+
+	if _, ok := s.apiKeys[parts[1]]; !ok {
+		s.writeError(w, 1, address, "unauthorized user")
+		return
+	}
+
+	if len(path) < 1 {
+		if r.Method != http.MethodGet {
+			s.methodNotAvailable(w, r.Method, address)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.fullState())
+		return
+	}
+
+	switch path[0] {
+	case "config":
+		s.handleConfig(w, r.Method, path, body)
+	case "lights":
+		s.handleLights(w, r.Method, path, body)
+	case "groups":
+		s.handleGroups(w, r.Method, path, body)
+	case "sensors":
+		s.handleSensors(w, r.Method, path, body)
+	case "rules", "schedules", "resourcelinks":
+		s.handleGeneric(w, r.Method, path, body, parts[1])
+	default:
+		s.writeError(w, 3, address, fmt.Sprintf("resource, %s, not available", address))
+	}
+}
+
+func (s *Server) methodNotAvailable(w http.ResponseWriter, method, address string) {
+	s.writeError(w, 4, address, fmt.Sprintf("method, %s, not available for resource, %s", method, address))
+}
+
+func (s *Server) resourceNotAvailable(w http.ResponseWriter, address string) {
+	s.writeError(w, 3, address, fmt.Sprintf("resource, %s, not available", address))
+}
+
+func (s *Server) createAPIKey(w http.ResponseWriter, body object) {
+	if !s.linkButton {
+		s.writeError(w, 101, "/", "link button not pressed")
+		return
+	}
+
+	deviceType, ok := body["devicetype"].(string)
+	if !ok || len(deviceType) < 1 {
+		s.writeError(w, 5, "/", "invalid/missing parameters in body")
+		return
+	}
+
+	key, _ := body["username"].(string)
+	if len(key) < 1 {
+		key = strings.ToUpper(etag())
+	}
+	s.apiKeys[key] = deviceType
+
+	s.writeJSON(w, http.StatusOK, []object{{"success": object{"username": key}}})
+}
+
+func (s *Server) configWithWhitelist() object {
+	ret := object{}
+	for k, v := range s.config {
+		ret[k] = v
+	}
+	now := time.Now()
+	ret["utc"] = timestamp(now)
+	ret["localtime"] = timestamp(now)
+	if tz, ok := s.config["timezone"].(string); ok {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			ret["localtime"] = now.In(loc).Format("2006-01-02T15:04:05")
+		}
+	}
+
+	whitelist := object{}
+	for key, name := range s.apiKeys {
+		whitelist[key] = object{"name": name, "create date": timestamp(now), "last use date": timestamp(now)}
+	}
+	ret["whitelist"] = whitelist
+	return ret
+}
+
+func (s *Server) fullState() object {
+	groups := object{}
+	for id := range s.resources["groups"] {
+		groups[id] = s.renderGroup(id)
+	}
+
+	ret := object{
+		"config": s.configWithWhitelist(),
+		"groups": groups,
+	}
+	for _, collection := range []string{"lights", "sensors", "rules", "schedules", "resourcelinks"} {
+		ret[collection] = s.resources[collection]
+	}
+	return ret
+}
+
+// configParams contains the validation of each writable config parameter.
+var configParams = map[string]func(interface{}) bool{
+	"name":          isString,
+	"rfconnected":   isBool,
+	"updatechannel": oneOf("stable", "alpha", "beta"),
+	"permitjoin":    inRange(0, 255),
+	"groupdelay":    inRange(0, 5000),
+	"otauactive":    isBool,
+	"discovery":     isBool,
+	"unlock":        inRange(0, 600),
+	"zigbeechannel": oneOfNumbers(11, 15, 20, 25),
+	"timezone":      isString,
+	"utc":           isString,
+	"timeformat":    oneOf("12h", "24h"),
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, method string, path []string, body object) {
+	address := "/" + strings.Join(path, "/")
+
+	switch {
+	case len(path) == 1 && method == http.MethodGet:
+		s.writeJSON(w, http.StatusOK, s.configWithWhitelist())
+	case len(path) == 1 && method == http.MethodPut:
+		var entries []object
+		for _, k := range sortedKeys(body) {
+			v := body[k]
+			valid, ok := configParams[k]
+			if !ok {
+				entries = append(entries, errorEntry(6, "/config/"+k, fmt.Sprintf("parameter, %s, not available", k)))
+				continue
+			}
+			if !valid(v) {
+				entries = append(entries, errorEntry(7, "/config/"+k, fmt.Sprintf("invalid value, %v, for parameter, %s", v, k)))
+				continue
+			}
+
+			if k == "unlock" {
+				s.linkButton = v.(float64) > 0
+				s.config["linkbutton"] = s.linkButton
+			} else if k != "utc" {
+				s.config[k] = v
+			}
+			entries = append(entries, successEntry("/config/"+k, v))
+		}
+		s.writeResults(w, entries)
+	case len(path) == 3 && path[1] == "whitelist" && method == http.MethodDelete:
+		if _, ok := s.apiKeys[path[2]]; !ok {
+			s.resourceNotAvailable(w, address)
+			return
+		}
+		delete(s.apiKeys, path[2])
+		s.writeJSON(w, http.StatusOK, []object{successEntry(address, "deleted")})
+	default:
+		s.methodNotAvailable(w, method, address)
+	}
+}
+
+func (s *Server) handleLights(w http.ResponseWriter, method string, path []string, body object) {
+	address := "/" + strings.Join(path, "/")
+
+	if len(path) == 1 {
+		switch method {
+		case http.MethodGet:
+			s.writeJSON(w, http.StatusOK, s.resources["lights"])
+		case http.MethodPost:
+			s.searches["lights"] = &search{started: time.Now()}
+			s.writeJSON(w, http.StatusOK, []object{successEntry("/lights", "Searching for new devices")})
+		default:
+			s.methodNotAvailable(w, method, address)
+		}
+		return
+	}
+
+	if path[1] == "new" && method == http.MethodGet {
+		s.writeJSON(w, http.StatusOK, s.newDevices("lights"))
+		return
+	}
+
+	id := path[1]
+	light, ok := s.resources["lights"][id]
+	if !ok {
+		s.resourceNotAvailable(w, address)
+		return
+	}
+
+	switch {
+	case len(path) == 2 && method == http.MethodGet:
+		s.writeJSON(w, http.StatusOK, light)
+	case len(path) == 2 && method == http.MethodPut:
+		var entries []object
+		for _, k := range sortedKeys(body) {
+			v := body[k]
+			if k != "name" {
+				entries = append(entries, errorEntry(6, "/lights/"+id+"/"+k, fmt.Sprintf("parameter, %s, not available", k)))
+				continue
+			}
+			if !isString(v) {
+				entries = append(entries, errorEntry(7, "/lights/"+id+"/"+k, fmt.Sprintf("invalid value, %v, for parameter, %s", v, k)))
+				continue
+			}
+			light[k] = v
+			entries = append(entries, successEntry("/lights/"+id+"/"+k, v))
+			s.broadcast(object{"t": "event", "e": "changed", "r": "lights", "id": id, "uniqueid": light["uniqueid"], "name": v})
+		}
+		s.writeResults(w, entries)
+	case len(path) == 2 && method == http.MethodDelete:
+		s.deleteResource("lights", id)
+		s.writeJSON(w, http.StatusOK, []object{successEntry("id", id)})
+	case len(path) == 3 && path[2] == "state" && method == http.MethodPut:
+		s.writeResults(w, s.setLightState(id, body))
+	case len(path) == 3 && path[2] == "groups" && method == http.MethodDelete:
+		for _, group := range s.resources["groups"] {
+			group["lights"] = removeString(group["lights"], id)
+		}
+		s.writeJSON(w, http.StatusOK, []object{successEntry(address, "deleted")})
+	case len(path) == 3 && path[2] == "scenes" && method == http.MethodDelete:
+		for _, scenes := range s.scenes {
+			for _, scene := range scenes {
+				scene["lights"] = removeSceneLight(scene["lights"], id)
+			}
+		}
+		s.writeJSON(w, http.StatusOK, []object{successEntry(address, "deleted")})
+	default:
+		s.methodNotAvailable(w, method, address)
+	}
+}
+
+// setLightState applies a state request to a light as the gateway would,
+// rejecting the fields the light doesn't support or can't change while off.
+func (s *Server) setLightState(id string, body object) []object {
+	light := s.resources["lights"][id]
+	state := subObject(light, "state")
+	prefix := "/lights/" + id + "/state/"
+
+	isOn, _ := state["on"].(bool)
+	if on, ok := body["on"].(bool); ok {
+		isOn = on
+	}
+	// Window coverings report whether they are closed through on, and can be moved either way
+	if _, ok := state["lift"]; ok {
+		isOn = true
+	}
+
+	var entries []object
+	changed := object{}
+	for _, k := range sortedKeys(body) {
+		v := body[k]
+
+		if k != "on" && k != "transitiontime" && !isOn {
+			entries = append(entries, errorEntry(201, prefix+k, fmt.Sprintf("parameter, %s, is not modifiable. Device is set to off.", k)))
+			continue
+		}
+
+		set, errType, desc := lightParam(state, light, k, v)
+		if errType != 0 {
+			entries = append(entries, errorEntry(errType, prefix+k, desc))
+			continue
+		}
+
+		for field, value := range set {
+			state[field] = value
+			changed[field] = value
+		}
+		entries = append(entries, successEntry(prefix+k, v))
+	}
+
+	if len(changed) > 0 {
+		s.broadcast(object{"t": "event", "e": "changed", "r": "lights", "id": id, "uniqueid": light["uniqueid"], "state": changed})
+	}
+	return entries
+}
+
+// lightParam validates a single light state parameter against the current state,
+// and returns the state fields to update. A non-zero error type is returned if the parameter is rejected.
+func lightParam(state, limits object, k string, v interface{}) (object, int, string) {
+	notAvailable := fmt.Sprintf("parameter, %s, not available", k)
+	invalid := fmt.Sprintf("invalid value, %v, for parameter, %s", v, k)
+
+	// The relative parameters update the same field as their absolute counterpart, and stop changes the lift.
+	base := strings.TrimSuffix(k, "_inc")
+	if k == "stop" {
+		base = "lift"
+	}
+	if _, ok := state[base]; !ok && k != "transitiontime" && k != "colorloopspeed" {
+		return nil, 6, notAvailable
+	}
+
+	switch k {
+	case "on":
+		if !isBool(v) {
+			return nil, 7, invalid
+		}
+		return object{"on": v}, 0, ""
+	case "transitiontime":
+		if !inRange(0, 65535)(v) {
+			return nil, 7, invalid
+		}
+		return nil, 0, ""
+	case "colorloopspeed":
+		if !inRange(1, 255)(v) {
+			return nil, 7, invalid
+		}
+		return nil, 0, ""
+	case "bri":
+		if !inRange(0, 255)(v) {
+			return nil, 7, invalid
+		}
+		return object{"bri": v}, 0, ""
+	case "hue":
+		if !inRange(0, 65535)(v) {
+			return nil, 7, invalid
+		}
+		return withColorMode(state, object{"hue": v}, "hs"), 0, ""
+	case "sat":
+		if !inRange(0, 255)(v) {
+			return nil, 7, invalid
+		}
+		return withColorMode(state, object{"sat": v}, "hs"), 0, ""
+	case "ct":
+		min, max := ctRange(limits)
+		if !inRange(min, max)(v) {
+			return nil, 7, invalid
+		}
+		return withColorMode(state, object{"ct": v}, "ct"), 0, ""
+	case "xy":
+		xy, ok := toXY(v, 0, 1)
+		if !ok {
+			return nil, 7, invalid
+		}
+		return withColorMode(state, object{"xy": xy}, "xy"), 0, ""
+	case "alert":
+		if !oneOf("none", "select", "lselect")(v) {
+			return nil, 7, invalid
+		}
+		return object{"alert": v}, 0, ""
+	case "effect":
+		if !oneOf("none", "colorloop")(v) {
+			return nil, 7, invalid
+		}
+		return object{"effect": v}, 0, ""
+	case "bri_inc":
+		if !inRange(-254, 254)(v) {
+			return nil, 7, invalid
+		}
+		return object{"bri": clamp(number(state["bri"])+v.(float64), 0, 254)}, 0, ""
+	case "sat_inc":
+		if !inRange(-254, 254)(v) {
+			return nil, 7, invalid
+		}
+		return withColorMode(state, object{"sat": clamp(number(state["sat"])+v.(float64), 0, 254)}, "hs"), 0, ""
+	case "hue_inc":
+		if !inRange(-65534, 65534)(v) {
+			return nil, 7, invalid
+		}
+		hue := math.Mod(number(state["hue"])+v.(float64)+65536, 65536)
+		return withColorMode(state, object{"hue": hue}, "hs"), 0, ""
+	case "ct_inc":
+		if !inRange(-65534, 65534)(v) {
+			return nil, 7, invalid
+		}
+		min, max := ctRange(limits)
+		return withColorMode(state, object{"ct": clamp(number(state["ct"])+v.(float64), min, max)}, "ct"), 0, ""
+	case "open":
+		if !isBool(v) {
+			return nil, 7, invalid
+		}
+		lift := 100.0
+		if v.(bool) {
+			lift = 0
+		}
+		return object{"open": v, "lift": lift, "on": !v.(bool)}, 0, ""
+	case "lift":
+		if v == "stop" {
+			return nil, 0, ""
+		}
+		if !inRange(0, 100)(v) {
+			return nil, 7, invalid
+		}
+		return object{"lift": v, "open": v.(float64) < 100, "on": v.(float64) > 0}, 0, ""
+	case "tilt":
+		if !inRange(0, 100)(v) {
+			return nil, 7, invalid
+		}
+		return object{"tilt": v}, 0, ""
+	case "stop":
+		if !isBool(v) {
+			return nil, 7, invalid
+		}
+		return nil, 0, ""
+	case "xy_inc":
+		inc, ok := toXY(v, -0.5, 0.5)
+		if !ok {
+			return nil, 7, invalid
+		}
+		cur, _ := toXY(state["xy"], 0, 1)
+		if cur == nil {
+			cur = []interface{}{0.0, 0.0}
+		}
+		xy := []interface{}{
+			clamp(cur[0].(float64)+inc[0].(float64), 0, 1),
+			clamp(cur[1].(float64)+inc[1].(float64), 0, 1),
+		}
+		return withColorMode(state, object{"xy": xy}, "xy"), 0, ""
+	}
+
+	return nil, 6, notAvailable
+}
+
+func withColorMode(state object, set object, mode string) object {
+	if _, ok := state["colormode"]; ok {
+		set["colormode"] = mode
+	}
+	return set
+}
+
+// ctRange returns the colour temperature limits of a light; a group has no limits of its own.
+func ctRange(resource object) (float64, float64) {
+	min, max := 153.0, 500.0
+	if v, ok := resource["ctmin"].(float64); ok && v > 0 {
+		min = v
+	}
+	if v, ok := resource["ctmax"].(float64); ok && v > 0 {
+		max = v
+	}
+	return min, max
+}
+
+func (s *Server) renderGroup(id string) object {
+	group := s.resources["groups"][id]
+	ret := object{}
+	for k, v := range group {
+		ret[k] = v
+	}
+	ret["id"] = id
+
+	scenes := []object{}
+	for _, sceneID := range sortedIDs(s.scenes[id]) {
+		scene := s.scenes[id][sceneID]
+		lights, _ := scene["lights"].([]interface{})
+		scenes = append(scenes, object{
+			"id":             sceneID,
+			"name":           scene["name"],
+			"transitiontime": 0,
+			"lightcount":     len(lights),
+		})
+	}
+	ret["scenes"] = scenes
+	return ret
+}
+
+func (s *Server) handleGroups(w http.ResponseWriter, method string, path []string, body object) {
+	address := "/" + strings.Join(path, "/")
+
+	if len(path) == 1 {
+		switch method {
+		case http.MethodGet:
+			groups := object{}
+			for id := range s.resources["groups"] {
+				groups[id] = s.renderGroup(id)
+			}
+			s.writeJSON(w, http.StatusOK, groups)
+		case http.MethodPost:
+			name, ok := body["name"].(string)
+			if !ok || len(name) < 1 {
+				s.writeError(w, 5, "/groups", "invalid/missing parameters in body")
+				return
+			}
+			id := s.addResource("groups", object{
+				"name":             name,
+				"type":             "LightGroup",
+				"hidden":           false,
+				"lights":           []interface{}{},
+				"lightsequence":    []interface{}{},
+				"multideviceids":   []interface{}{},
+				"devicemembership": []interface{}{},
+				"action":           object{"on": false, "bri": 0.0, "hue": 0.0, "sat": 0.0, "ct": 0.0, "xy": []interface{}{0.0, 0.0}, "effect": "none", "alert": "none", "colormode": "hs"},
+				"state":            object{"all_on": false, "any_on": false},
+			})
+			s.resources["groups"][id]["id"] = id
+			s.writeJSON(w, http.StatusOK, []object{successEntry("id", id)})
+		default:
+			s.methodNotAvailable(w, method, address)
+		}
+		return
+	}
+
+	id := path[1]
+	group, ok := s.resources["groups"][id]
+	if !ok {
+		s.resourceNotAvailable(w, address)
+		return
+	}
+
+	if len(path) > 2 && path[2] == "scenes" {
+		s.handleScenes(w, method, path, body, id)
+		return
+	}
+
+	switch {
+	case len(path) == 2 && method == http.MethodGet:
+		s.writeJSON(w, http.StatusOK, s.renderGroup(id))
+	case len(path) == 2 && method == http.MethodPut:
+		var entries []object
+		for _, k := range sortedKeys(body) {
+			v := body[k]
+			var valid func(interface{}) bool
+			switch k {
+			case "name":
+				valid = isString
+			case "hidden":
+				valid = isBool
+			case "lights":
+				valid = s.isLightList
+			case "lightsequence", "multideviceids":
+				valid = isStringList
+			default:
+				entries = append(entries, errorEntry(6, "/groups/"+id+"/"+k, fmt.Sprintf("parameter, %s, not available", k)))
+				continue
+			}
+			if !valid(v) {
+				entries = append(entries, errorEntry(7, "/groups/"+id+"/"+k, fmt.Sprintf("invalid value, %v, for parameter, %s", v, k)))
+				continue
+			}
+			group[k] = v
+			entries = append(entries, successEntry("/groups/"+id+"/"+k, v))
+		}
+		s.writeResults(w, entries)
+	case len(path) == 2 && method == http.MethodDelete:
+		s.deleteResource("groups", id)
+		s.writeJSON(w, http.StatusOK, []object{successEntry("id", id)})
+	case len(path) == 3 && path[2] == "action" && method == http.MethodPut:
+		s.writeResults(w, s.setGroupAction(id, body))
+	default:
+		s.methodNotAvailable(w, method, address)
+	}
+}
+
+// setGroupAction applies a state request to every light in the group. The values are checked against
+// the group action; individual lights silently ignore the fields they don't support.
+func (s *Server) setGroupAction(id string, body object) []object {
+	group := s.resources["groups"][id]
+	action := subObject(group, "action")
+	prefix := "/groups/" + id + "/action/"
+
+	var entries []object
+	lightBody := object{}
+	for _, k := range sortedKeys(body) {
+		v := body[k]
+
+		if k == "toggle" {
+			if !isBool(v) {
+				entries = append(entries, errorEntry(7, prefix+k, fmt.Sprintf("invalid value, %v, for parameter, %s", v, k)))
+				continue
+			}
+			entries = append(entries, successEntry(prefix+k, v))
+			continue
+		}
+
+		set, errType, desc := lightParam(action, group, k, v)
+		if errType != 0 {
+			entries = append(entries, errorEntry(errType, prefix+k, desc))
+			continue
+		}
+		for field, value := range set {
+			action[field] = value
+		}
+		lightBody[k] = v
+		entries = append(entries, successEntry(prefix+k, v))
+	}
+
+	toggle, _ := body["toggle"].(bool)
+	for _, lightID := range stringList(group["lights"]) {
+		light, ok := s.resources["lights"][lightID]
+		if !ok {
+			continue
+		}
+
+		state := subObject(light, "state")
+		changed := object{}
+		req := object{}
+		for k, v := range lightBody {
+			req[k] = v
+		}
+		if toggle {
+			isOn, _ := state["on"].(bool)
+			req["on"] = !isOn
+		}
+		for _, k := range sortedKeys(req) {
+			set, errType, _ := lightParam(state, light, k, req[k])
+			if errType != 0 {
+				continue
+			}
+			for field, value := range set {
+				state[field] = value
+				changed[field] = value
+			}
+		}
+		if len(changed) > 0 {
+			s.broadcast(object{"t": "event", "e": "changed", "r": "lights", "id": lightID, "uniqueid": light["uniqueid"], "state": changed})
+		}
+	}
+
+	s.updateGroupState(id)
+	return entries
+}
+
+// updateGroupState recalculates whether any or all of the lights in the group are on.
+func (s *Server) updateGroupState(id string) {
+	group := s.resources["groups"][id]
+	lightIDs := stringList(group["lights"])
+
+	anyOn, allOn := false, len(lightIDs) > 0
+	for _, lightID := range lightIDs {
+		light, ok := s.resources["lights"][lightID]
+		if !ok {
+			continue
+		}
+		isOn, _ := subObject(light, "state")["on"].(bool)
+		anyOn = anyOn || isOn
+		allOn = allOn && isOn
+	}
+
+	state := subObject(group, "state")
+	if state["any_on"] == anyOn && state["all_on"] == allOn {
+		return
+	}
+	state["any_on"] = anyOn
+	state["all_on"] = allOn
+	s.broadcast(object{"t": "event", "e": "changed", "r": "groups", "id": id, "state": object{"any_on": anyOn, "all_on": allOn}})
+}
+
+func (s *Server) handleScenes(w http.ResponseWriter, method string, path []string, body object, groupID string) {
+	address := "/" + strings.Join(path, "/")
+	scenes, ok := s.scenes[groupID]
+	if !ok {
+		scenes = map[string]object{}
+		s.scenes[groupID] = scenes
+	}
+
+	if len(path) == 3 {
+		switch method {
+		case http.MethodGet:
+			ret := object{}
+			for id, scene := range scenes {
+				var lightIDs []string
+				lights, _ := scene["lights"].([]interface{})
+				for _, light := range lights {
+					lightIDs = append(lightIDs, light.(object)["id"].(string))
+				}
+				ret[id] = object{"name": scene["name"], "lights": lightIDs}
+			}
+			s.writeJSON(w, http.StatusOK, ret)
+		case http.MethodPost:
+			name, ok := body["name"].(string)
+			if !ok || len(name) < 1 {
+				s.writeError(w, 5, address, "invalid/missing parameters in body")
+				return
+			}
+			s.nextID["scenes"]++
+			id := fmt.Sprintf("%d", s.nextID["scenes"])
+			scenes[id] = object{"name": name, "lights": s.captureScene(groupID)}
+			s.writeJSON(w, http.StatusOK, []object{successEntry("id", id)})
+		default:
+			s.methodNotAvailable(w, method, address)
+		}
+		return
+	}
+
+	sceneID := path[3]
+	scene, ok := scenes[sceneID]
+	if !ok {
+		s.resourceNotAvailable(w, address)
+		return
+	}
+
+	switch {
+	case len(path) == 4 && method == http.MethodGet:
+		s.writeJSON(w, http.StatusOK, scene)
+	case len(path) == 4 && method == http.MethodPut:
+		name, ok := body["name"].(string)
+		if !ok || len(name) < 1 {
+			s.writeError(w, 7, address+"/name", fmt.Sprintf("invalid value, %v, for parameter, name", body["name"]))
+			return
+		}
+		scene["name"] = name
+		s.writeJSON(w, http.StatusOK, []object{successEntry(address+"/name", name)})
+	case len(path) == 4 && method == http.MethodDelete:
+		delete(scenes, sceneID)
+		s.writeJSON(w, http.StatusOK, []object{successEntry("id", sceneID)})
+	case len(path) == 5 && path[4] == "store" && method == http.MethodPut:
+		scene["lights"] = s.captureScene(groupID)
+		s.writeJSON(w, http.StatusOK, []object{successEntry("id", sceneID)})
+	case len(path) == 5 && path[4] == "recall" && method == http.MethodPut:
+		lights, _ := scene["lights"].([]interface{})
+		for _, entry := range lights {
+			sceneLight := entry.(object)
+			lightID := sceneLight["id"].(string)
+			if _, ok := s.resources["lights"][lightID]; !ok {
+				continue
+			}
+			req := object{"on": sceneLight["on"], "bri": sceneLight["bri"]}
+			if ct, ok := sceneLight["ct"].(float64); ok && ct > 0 {
+				req["ct"] = ct
+			} else if x, ok := sceneLight["x"].(float64); ok {
+				req["xy"] = []interface{}{x, sceneLight["y"]}
+			}
+			s.setLightState(lightID, req)
+		}
+		s.updateGroupState(groupID)
+		s.broadcast(object{"t": "event", "e": "scene-called", "r": "scenes", "gid": groupID, "scid": sceneID})
+		s.writeJSON(w, http.StatusOK, []object{successEntry("id", sceneID)})
+	case len(path) == 7 && path[4] == "lights" && path[6] == "state" && method == http.MethodPut:
+		lights, _ := scene["lights"].([]interface{})
+		for _, entry := range lights {
+			sceneLight := entry.(object)
+			if sceneLight["id"] != path[5] {
+				continue
+			}
+			var entries []object
+			for _, k := range sortedKeys(body) {
+				v := body[k]
+				switch k {
+				case "on", "bri", "transitiontime", "ct", "hue", "sat":
+					sceneLight[k] = v
+				case "xy":
+					xy, ok := toXY(v, 0, 1)
+					if !ok {
+						entries = append(entries, errorEntry(7, address+"/"+k, fmt.Sprintf("invalid value, %v, for parameter, %s", v, k)))
+						continue
+					}
+					sceneLight["x"], sceneLight["y"] = xy[0], xy[1]
+				default:
+					entries = append(entries, errorEntry(6, address+"/"+k, fmt.Sprintf("parameter, %s, not available", k)))
+					continue
+				}
+				entries = append(entries, successEntry(address+"/"+k, v))
+			}
+			s.writeResults(w, entries)
+			return
+		}
+		s.resourceNotAvailable(w, address)
+	default:
+		s.methodNotAvailable(w, method, address)
+	}
+}
+
+// captureScene records the current state of every light in the group.
+func (s *Server) captureScene(groupID string) []interface{} {
+	lights := []interface{}{}
+	for _, lightID := range stringList(s.resources["groups"][groupID]["lights"]) {
+		light, ok := s.resources["lights"][lightID]
+		if !ok {
+			continue
+		}
+		state := subObject(light, "state")
+		xy, _ := toXY(state["xy"], 0, 1)
+		if xy == nil {
+			xy = []interface{}{0.0, 0.0}
+		}
+		lights = append(lights, object{
+			"id":             lightID,
+			"on":             state["on"],
+			"bri":            number(state["bri"]),
+			"transitiontime": 0.0,
+			"x":              xy[0],
+			"y":              xy[1],
+			"ct":             number(state["ct"]),
+			"hue":            number(state["hue"]),
+			"sat":            number(state["sat"]),
+		})
+	}
+	return lights
+}
+
+func (s *Server) handleSensors(w http.ResponseWriter, method string, path []string, body object) {
+	address := "/" + strings.Join(path, "/")
+
+	if len(path) == 1 {
+		switch method {
+		case http.MethodGet:
+			s.writeJSON(w, http.StatusOK, s.resources["sensors"])
+		case http.MethodPost:
+			if len(body) < 1 {
+				s.searches["sensors"] = &search{started: time.Now()}
+				s.writeJSON(w, http.StatusOK, []object{successEntry("/sensors", "Searching for new devices")})
+				return
+			}
+			s.createSensor(w, body)
+		default:
+			s.methodNotAvailable(w, method, address)
+		}
+		return
+	}
+
+	if path[1] == "new" && method == http.MethodGet {
+		s.writeJSON(w, http.StatusOK, s.newDevices("sensors"))
+		return
+	}
+
+	id := path[1]
+	sensor, ok := s.resources["sensors"][id]
+	if !ok {
+		s.resourceNotAvailable(w, address)
+		return
+	}
+
+	switch {
+	case len(path) == 2 && method == http.MethodGet:
+		s.writeJSON(w, http.StatusOK, sensor)
+	case len(path) == 2 && method == http.MethodPut:
+		var entries []object
+		for _, k := range sortedKeys(body) {
+			v := body[k]
+			var valid func(interface{}) bool
+			switch k {
+			case "name":
+				valid = isString
+			case "mode":
+				valid = inRange(1, 3)
+			default:
+				entries = append(entries, errorEntry(6, "/sensors/"+id+"/"+k, fmt.Sprintf("parameter, %s, not available", k)))
+				continue
+			}
+			if !valid(v) {
+				entries = append(entries, errorEntry(7, "/sensors/"+id+"/"+k, fmt.Sprintf("invalid value, %v, for parameter, %s", v, k)))
+				continue
+			}
+			sensor[k] = v
+			entries = append(entries, successEntry("/sensors/"+id+"/"+k, v))
+			if k == "name" {
+				s.broadcast(object{"t": "event", "e": "changed", "r": "sensors", "id": id, "uniqueid": sensor["uniqueid"], "name": v})
+			}
+		}
+		s.writeResults(w, entries)
+	case len(path) == 2 && method == http.MethodDelete:
+		s.deleteResource("sensors", id)
+		s.writeJSON(w, http.StatusOK, []object{successEntry("id", id)})
+	case len(path) == 3 && path[2] == "config" && method == http.MethodPut:
+		// CLIP sensors report the reachability and battery of the device they represent, so they are set by the client
+		sensorType, _ := sensor["type"].(string)
+		clip := strings.HasPrefix(sensorType, "CLIP")
+		s.writeResults(w, s.setSensorField(id, "config", body, func(k string) bool {
+			return k == "pending" || (!clip && (k == "reachable" || k == "battery"))
+		}))
+	case len(path) == 3 && path[2] == "state" && method == http.MethodPut:
+		sensorType, _ := sensor["type"].(string)
+		clip := strings.HasPrefix(sensorType, "CLIP")
+		entries := s.setSensorField(id, "state", body, func(k string) bool {
+			return !clip || k == "lastupdated"
+		})
+		s.writeResults(w, entries)
+	default:
+		s.methodNotAvailable(w, method, address)
+	}
+}
+
+// setSensorField updates the existing keys of the sensor state or config, rejecting the read only ones.
+func (s *Server) setSensorField(id, field string, body object, readOnly func(string) bool) []object {
+	sensor := s.resources["sensors"][id]
+	target := subObject(sensor, field)
+	prefix := "/sensors/" + id + "/" + field + "/"
+
+	var entries []object
+	changed := object{}
+	for _, k := range sortedKeys(body) {
+		v := body[k]
+		current, ok := target[k]
+		if !ok {
+			entries = append(entries, errorEntry(6, prefix+k, fmt.Sprintf("parameter, %s, not available", k)))
+			continue
+		}
+		if readOnly(k) {
+			entries = append(entries, errorEntry(8, prefix+k, fmt.Sprintf("parameter, %s, not modifiable", k)))
+			continue
+		}
+		if current != nil && !sameKind(current, v) {
+			entries = append(entries, errorEntry(7, prefix+k, fmt.Sprintf("invalid value, %v, for parameter, %s", v, k)))
+			continue
+		}
+		target[k] = v
+		changed[k] = v
+		entries = append(entries, successEntry(prefix+k, v))
+	}
+
+	if len(changed) > 0 {
+		if field == "state" {
+			target["lastupdated"] = timestamp(time.Now())
+			changed["lastupdated"] = target["lastupdated"]
+		}
+		s.broadcast(object{"t": "event", "e": "changed", "r": "sensors", "id": id, "uniqueid": sensor["uniqueid"], field: changed})
+	}
+	return entries
+}
+
+// createSensor creates a CLIP sensor from the supplied fields.
+func (s *Server) createSensor(w http.ResponseWriter, body object) {
+	for _, k := range []string{"name", "type", "modelid", "manufacturername", "swversion", "uniqueid"} {
+		if !isString(body[k]) {
+			s.writeError(w, 5, "/sensors", fmt.Sprintf("invalid/missing parameters in body, %s", k))
+			return
+		}
+	}
+	sensorType := body["type"].(string)
+	if !strings.HasPrefix(sensorType, "CLIP") {
+		s.writeError(w, 7, "/sensors/type", fmt.Sprintf("invalid value, %s, for parameter, type", sensorType))
+		return
+	}
+
+	obj := object{}
+	for k, v := range body {
+		obj[k] = v
+	}
+	state := subObject(obj, "state")
+	state["lastupdated"] = timestamp(time.Now())
+	config := subObject(obj, "config")
+	if _, ok := config["on"]; !ok {
+		config["on"] = true
+	}
+	if _, ok := config["reachable"]; !ok {
+		config["reachable"] = true
+	}
+
+	id := s.addResource("sensors", obj)
+	s.broadcast(object{"t": "event", "e": "added", "r": "sensors", "id": id, "uniqueid": obj["uniqueid"], "sensor": obj})
+	s.writeJSON(w, http.StatusOK, []object{successEntry("id", id)})
+}
+
+// newDevices builds the response for the devices found by the last search.
+func (s *Server) newDevices(collection string) object {
+	ret := object{"lastscan": "none"}
+
+	srch, ok := s.searches[collection]
+	if !ok {
+		return ret
+	}
+
+	if time.Since(srch.started) < searchDuration {
+		ret["lastscan"] = "active"
+	} else {
+		ret["lastscan"] = timestamp(srch.started.Add(searchDuration))
+	}
+	for _, id := range srch.found {
+		if obj, ok := s.resources[collection][id]; ok {
+			ret[id] = object{"name": obj["name"]}
+		}
+	}
+	return ret
+}
+
+// genericParams contains the required parameters on creation, and the modifiable parameters, of the simple collections.
+var genericParams = map[string]struct {
+	required   []string
+	modifiable []string
+}{
+	"rules": {
+		required:   []string{"name", "conditions", "actions"},
+		modifiable: []string{"name", "status", "periodic", "actions", "conditions"},
+	},
+	"schedules": {
+		required:   []string{"command", "time"},
+		modifiable: []string{"name", "description", "command", "status", "autodelete", "time", "localtime"},
+	},
+	"resourcelinks": {
+		required:   []string{"name", "classid", "links"},
+		modifiable: []string{"name", "description", "classid", "links", "recycle"},
+	},
+}
+
+func (s *Server) handleGeneric(w http.ResponseWriter, method string, path []string, body object, apiKey string) {
+	address := "/" + strings.Join(path, "/")
+	collection := path[0]
+	params := genericParams[collection]
+
+	if len(path) == 1 {
+		switch method {
+		case http.MethodGet:
+			s.writeJSON(w, http.StatusOK, s.resources[collection])
+		case http.MethodPost:
+			for _, k := range params.required {
+				if _, ok := body[k]; !ok {
+					s.writeError(w, 5, address, fmt.Sprintf("invalid/missing parameters in body, %s", k))
+					return
+				}
+			}
+
+			now := timestamp(time.Now())
+			obj := object{"owner": apiKey, "created": now}
+			switch collection {
+			case "rules":
+				obj["status"] = "enabled"
+				obj["periodic"] = 0.0
+				obj["lasttriggered"] = "none"
+				obj["timestriggered"] = 0.0
+			case "schedules":
+				obj["name"] = "Schedule"
+				obj["description"] = ""
+				obj["status"] = "enabled"
+				obj["autodelete"] = true
+			case "resourcelinks":
+				obj["type"] = "Link"
+				obj["description"] = ""
+				obj["recycle"] = false
+			}
+			for k, v := range body {
+				obj[k] = v
+			}
+
+			id := s.addResource(collection, obj)
+			s.writeJSON(w, http.StatusOK, []object{successEntry("id", id)})
+		default:
+			s.methodNotAvailable(w, method, address)
+		}
+		return
+	}
+
+	id := path[1]
+	obj, ok := s.resources[collection][id]
+	if !ok || len(path) > 2 {
+		s.resourceNotAvailable(w, address)
+		return
+	}
+
+	switch method {
+	case http.MethodGet:
+		s.writeJSON(w, http.StatusOK, obj)
+	case http.MethodPut:
+		var entries []object
+		for _, k := range sortedKeys(body) {
+			if !contains(params.modifiable, k) {
+				entries = append(entries, errorEntry(6, address+"/"+k, fmt.Sprintf("parameter, %s, not available", k)))
+				continue
+			}
+			obj[k] = body[k]
+			entries = append(entries, successEntry(address+"/"+k, body[k]))
+		}
+		obj["etag"] = etag()
+		s.writeResults(w, entries)
+	case http.MethodDelete:
+		s.deleteResource(collection, id)
+		s.writeJSON(w, http.StatusOK, []object{successEntry("id", id)})
+	default:
+		s.methodNotAvailable(w, method, address)
+	}
+}
+
+func (s *Server) isLightList(v interface{}) bool {
+	if !isStringList(v) {
+		return false
+	}
+	for _, id := range stringList(v) {
+		if _, ok := s.resources["lights"][id]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func isBool(v interface{}) bool {
+	_, ok := v.(bool)
+	return ok
+}
+
+func isString(v interface{}) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+func isStringList(v interface{}) bool {
+	list, ok := v.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, entry := range list {
+		if !isString(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+func inRange(min, max float64) func(interface{}) bool {
+	return func(v interface{}) bool {
+		n, ok := v.(float64)
+		return ok && n >= min && n <= max && n == math.Trunc(n)
+	}
+}
+
+func oneOf(values ...string) func(interface{}) bool {
+	return func(v interface{}) bool {
+		str, ok := v.(string)
+		return ok && contains(values, str)
+	}
+}
+
+func oneOfNumbers(values ...float64) func(interface{}) bool {
+	return func(v interface{}) bool {
+		n, ok := v.(float64)
+		if !ok {
+			return false
+		}
+		for _, value := range values {
+			if n == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func sameKind(a, b interface{}) bool {
+	switch a.(type) {
+	case bool:
+		return isBool(b)
+	case string:
+		return isString(b)
+	case float64:
+		_, ok := b.(float64)
+		return ok
+	}
+	return true
+}
+
+func toXY(v interface{}, min, max float64) ([]interface{}, bool) {
+	list, ok := v.([]interface{})
+	if !ok || len(list) != 2 {
+		return nil, false
+	}
+	for _, entry := range list {
+		n, ok := entry.(float64)
+		if !ok || n < min || n > max {
+			return nil, false
+		}
+	}
+	return list, true
+}
+
+func number(v interface{}) float64 {
+	n, _ := v.(float64)
+	return n
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
+
+func contains(list []string, v string) bool {
+	for _, entry := range list {
+		if entry == v {
+			return true
+		}
+	}
+	return false
+}
+
+func stringList(v interface{}) []string {
+	var ret []string
+	switch list := v.(type) {
+	case []interface{}:
+		for _, entry := range list {
+			if str, ok := entry.(string); ok {
+				ret = append(ret, str)
+			}
+		}
+	case []string:
+		ret = list
+	}
+	return ret
+}
+
+func removeString(v interface{}, id string) []interface{} {
+	ret := []interface{}{}
+	for _, entry := range stringList(v) {
+		if entry != id {
+			ret = append(ret, entry)
+		}
+	}
+	return ret
+}
+
+func removeSceneLight(v interface{}, id string) []interface{} {
+	ret := []interface{}{}
+	lights, _ := v.([]interface{})
+	for _, entry := range lights {
+		if light, ok := entry.(object); ok && light["id"] == id {
+			continue
+		}
+		ret = append(ret, entry)
+	}
+	return ret
+}
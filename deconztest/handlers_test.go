@@ -0,0 +1,218 @@
+package deconztest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rmrobinson/deconz-go"
+)
+
+// request sends a raw request to the REST API and returns the status and body.
+func request(t *testing.T, s *Server, method, path, body string) (int, string) {
+	t.Helper()
+
+	r, err := http.NewRequest(method, s.rest.URL+"/api/"+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("creating request returned error: %v", err)
+	}
+	resp, err := s.rest.Client().Do(r)
+	if err != nil {
+		t.Fatalf("%s %s returned error: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading %s %s returned error: %v", method, path, err)
+	}
+	return resp.StatusCode, strings.TrimSpace(string(b))
+}
+
+// errorTypes returns the type of each error entry in a response.
+func errorTypes(t *testing.T, body string) []int {
+	t.Helper()
+
+	var entries []struct {
+		Error *struct {
+			Type int `json:"type"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(body), &entries); err != nil {
+		t.Fatalf("response %s is not a list of entries: %v", body, err)
+	}
+
+	var types []int
+	for _, entry := range entries {
+		if entry.Error != nil {
+			types = append(types, entry.Error.Type)
+		}
+	}
+	return types
+}
+
+func TestErrorStatus(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	tests := []struct {
+		method  string
+		path    string
+		body    string
+		status  int
+		errType int
+	}{
+		{method: http.MethodGet, path: "unknown/lights", status: http.StatusForbidden, errType: 1},
+		{method: http.MethodGet, path: DefaultAPIKey + "/lights/9", status: http.StatusNotFound, errType: 3},
+		{method: http.MethodGet, path: DefaultAPIKey + "/things", status: http.StatusNotFound, errType: 3},
+		{method: http.MethodDelete, path: DefaultAPIKey + "/config", status: http.StatusMethodNotAllowed, errType: 4},
+		{method: http.MethodPost, path: DefaultAPIKey + "/rules", body: `{"name":"Away"}`, status: http.StatusBadRequest, errType: 5},
+		{method: http.MethodPut, path: DefaultAPIKey + "/config", body: `{"name":`, status: http.StatusBadRequest, errType: 2},
+		{method: http.MethodPut, path: DefaultAPIKey + "/config", body: `{"zigbeechannel":12}`, status: http.StatusBadRequest, errType: 7},
+	}
+
+	for _, tt := range tests {
+		status, body := request(t, s, tt.method, tt.path, tt.body)
+		if status != tt.status {
+			t.Errorf("%s %s returned status %d, want %d", tt.method, tt.path, status, tt.status)
+		}
+		if types := errorTypes(t, body); len(types) != 1 || types[0] != tt.errType {
+			t.Errorf("%s %s returned %s, want one error of type %d", tt.method, tt.path, body, tt.errType)
+		}
+	}
+}
+
+func TestEmptyUpdateWritesList(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	status, body := request(t, s, http.MethodPost, DefaultAPIKey+"/rules",
+		`{"name":"Away","conditions":[{"address":"/sensors/1/state/flag","operator":"eq","value":"true"}],"actions":[]}`)
+	if status != http.StatusOK || body != `[{"success":{"id":"1"}}]` {
+		t.Fatalf("creating a rule returned %d %s, want the new ID", status, body)
+	}
+
+	// The gateway reports an empty list, not null, when nothing is changed
+	status, body = request(t, s, http.MethodPut, DefaultAPIKey+"/rules/1", `{}`)
+	if status != http.StatusOK || body != "[]" {
+		t.Errorf("an empty update returned %d %s, want 200 []", status, body)
+	}
+}
+
+func TestUpdatePartialSuccess(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	// Some fields applied and some rejected is reported as success, with the errors alongside
+	status, body := request(t, s, http.MethodPut, DefaultAPIKey+"/config", `{"name":"Home","timeformat":"36h"}`)
+	if status != http.StatusOK {
+		t.Errorf("a partially applied update returned status %d, want 200", status)
+	}
+	if types := errorTypes(t, body); len(types) != 1 || types[0] != 7 {
+		t.Errorf("a partially applied update returned %s, want one invalid value error", body)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.config["name"] != "Home" || s.config["timeformat"] != "24h" {
+		t.Errorf("config = %v, want only the name changed", s.config)
+	}
+}
+
+func TestSensorReadOnlyFields(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	door := deconz.Sensor{}
+	door.Name = "Front door"
+	door.Type = "ZHAOpenClose"
+	door.UniqueID = "00:11:22:33:44:55:66:aa-01-0006"
+	door.StateRaw = json.RawMessage(`{"open":false,"lastupdated":"none"}`)
+	door.ConfigRaw = json.RawMessage(`{"on":true,"reachable":true,"battery":90,"pending":[]}`)
+	doorID := s.AddSensor(door)
+
+	flag := deconz.Sensor{}
+	flag.Name = "Away"
+	flag.Type = "CLIPGenericFlag"
+	flag.UniqueID = "away"
+	flag.StateRaw = json.RawMessage(`{"flag":false,"lastupdated":"none"}`)
+	flag.ConfigRaw = json.RawMessage(`{"on":true,"reachable":true,"battery":100}`)
+	flagID := s.AddSensor(flag)
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		errType int
+	}{
+		{name: "Zigbee state", path: "sensors/" + doorID + "/state", body: `{"open":true}`, errType: 8},
+		{name: "Zigbee battery", path: "sensors/" + doorID + "/config", body: `{"battery":50}`, errType: 8},
+		{name: "Zigbee reachable", path: "sensors/" + doorID + "/config", body: `{"reachable":false}`, errType: 8},
+		{name: "pending", path: "sensors/" + doorID + "/config", body: `{"pending":[1]}`, errType: 8},
+		{name: "Zigbee on", path: "sensors/" + doorID + "/config", body: `{"on":false}`},
+		{name: "unknown config", path: "sensors/" + doorID + "/config", body: `{"delay":10}`, errType: 6},
+		{name: "wrong type", path: "sensors/" + doorID + "/config", body: `{"on":"off"}`, errType: 7},
+		{name: "CLIP state", path: "sensors/" + flagID + "/state", body: `{"flag":true}`},
+		{name: "CLIP lastupdated", path: "sensors/" + flagID + "/state", body: `{"lastupdated":"2021-03-04T05:06:07"}`, errType: 8},
+		{name: "CLIP battery", path: "sensors/" + flagID + "/config", body: `{"battery":50}`},
+		{name: "CLIP reachable", path: "sensors/" + flagID + "/config", body: `{"reachable":false}`},
+	}
+
+	for _, tt := range tests {
+		status, body := request(t, s, http.MethodPut, DefaultAPIKey+"/"+tt.path, tt.body)
+		types := errorTypes(t, body)
+		if tt.errType == 0 {
+			if status != http.StatusOK || len(types) != 0 {
+				t.Errorf("%s: update returned %d %s, want success", tt.name, status, body)
+			}
+			continue
+		}
+		if len(types) != 1 || types[0] != tt.errType {
+			t.Errorf("%s: update returned %s, want one error of type %d", tt.name, body, tt.errType)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state := subObject(s.resources["sensors"][doorID], "state"); state["open"] != false {
+		t.Errorf("door state = %v, want it unchanged", state)
+	}
+	if config := subObject(s.resources["sensors"][flagID], "config"); config["battery"] != 50.0 || config["reachable"] != false {
+		t.Errorf("flag config = %v, want the battery and reachability set", config)
+	}
+}
+
+func TestAPIKeyRequiresLinkButton(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	status, body := request(t, s, http.MethodPost, "", `{"devicetype":"deconztest#test"}`)
+	if types := errorTypes(t, body); status != http.StatusForbidden || len(types) != 1 || types[0] != 101 {
+		t.Fatalf("creating a key while locked returned %d %s, want a link button error", status, body)
+	}
+
+	s.PressLinkButton()
+	status, body = request(t, s, http.MethodPost, "", `{}`)
+	if types := errorTypes(t, body); status != http.StatusBadRequest || len(types) != 1 || types[0] != 5 {
+		t.Errorf("creating a key without a device type returned %d %s, want a missing parameter error", status, body)
+	}
+
+	status, body = request(t, s, http.MethodPost, "", `{"devicetype":"deconztest#test","username":"1234567890"}`)
+	if status != http.StatusOK || body != `[{"success":{"username":"1234567890"}}]` {
+		t.Fatalf("creating a key returned %d %s, want the requested key", status, body)
+	}
+
+	status, body = request(t, s, http.MethodGet, "1234567890/config", "")
+	if status != http.StatusOK || !strings.Contains(body, `"deconztest#test"`) {
+		t.Errorf("config with the new key returned %d %s, want the key in the whitelist", status, body)
+	}
+
+	status, _ = request(t, s, http.MethodDelete, DefaultAPIKey+"/config/whitelist/1234567890", "")
+	if status != http.StatusOK {
+		t.Errorf("deleting the key returned status %d, want 200", status)
+	}
+	if status, _ = request(t, s, http.MethodGet, "1234567890/config", ""); status != http.StatusForbidden {
+		t.Errorf("config with the deleted key returned status %d, want 403", status)
+	}
+}
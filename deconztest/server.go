@@ -0,0 +1,519 @@
+// Package deconztest provides an in-memory deCONZ gateway for use in tests.
+// It serves the REST API and the websocket from local HTTP servers, so the deconz client
+// and anything built on top of it can be exercised without any hardware.
+package deconztest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rmrobinson/deconz-go"
+)
+
+// DefaultAPIKey is the API key which is authorized on every new server.
+const DefaultAPIKey = "deconztest"
+
+// searchDuration is how long a search for new devices stays active, matching the gateway.
+const searchDuration = time.Minute
+
+// Server is a fake deCONZ gateway. The zero value is not usable; create one with NewServer.
+type Server struct {
+	rest *httptest.Server
+	ws   *httptest.Server
+
+	upgrader websocket.Upgrader
+
+	mu         sync.Mutex
+	conns      map[*websocket.Conn]bool
+	apiKeys    map[string]string
+	linkButton bool
+	config     object
+	// resources contains the lights, groups, sensors, rules, schedules and resourcelinks, keyed by collection then ID.
+	resources map[string]map[string]object
+	// scenes contains the scenes of each group, keyed by group ID then scene ID.
+	scenes map[string]map[string]object
+	nextID map[string]int
+	// searches contains the start time of the last search and the devices found since, keyed by collection.
+	searches map[string]*search
+}
+
+// object is the generic JSON form all resources are stored in.
+type object map[string]interface{}
+
+type search struct {
+	started time.Time
+	found   []string
+}
+
+// NewServer starts a new fake gateway with no devices, and DefaultAPIKey authorized.
+// The server should be stopped with Close once it is no longer needed.
+func NewServer() *Server {
+	s := &Server{
+		conns:   map[*websocket.Conn]bool{},
+		apiKeys: map[string]string{DefaultAPIKey: "deconztest"},
+		resources: map[string]map[string]object{
+			"groups":        {},
+			"lights":        {},
+			"resourcelinks": {},
+			"rules":         {},
+			"schedules":     {},
+			"sensors":       {},
+		},
+		scenes:   map[string]map[string]object{},
+		nextID:   map[string]int{},
+		searches: map[string]*search{},
+	}
+
+	s.rest = httptest.NewServer(http.HandlerFunc(s.serveREST))
+	s.ws = httptest.NewServer(http.HandlerFunc(s.serveWebsocket))
+
+	wsURL, _ := url.Parse(s.ws.URL)
+	wsPort, _ := strconv.Atoi(wsURL.Port())
+
+	s.config = object{
+		"apiversion":         "1.16.0",
+		"swversion":          "2.5.0",
+		"swupdate":           object{"notify": false, "text": "", "updatestate": 0, "url": ""},
+		"name":               "deconztest",
+		"mac":                "00:21:2e:ff:ff:00",
+		"uuid":               "a65d80a1-975a-4598-8d5a-2547bc15d9c0",
+		"zigbeechannel":      15,
+		"panid":              42484,
+		"websocketnotifyall": true,
+		"websocketport":      wsPort,
+		"linkbutton":         false,
+		"permitjoin":         0,
+		"otauactive":         true,
+		"discovery":          true,
+		"rfconnected":        true,
+		"updatechannel":      "stable",
+		"groupdelay":         50,
+		"timeformat":         "24h",
+		"timezone":           "Etc/GMT",
+		"dhcp":               true,
+		"gateway":            "127.0.0.1",
+		"ipaddress":          "127.0.0.1",
+		"netmask":            "255.0.0.0",
+	}
+
+	return s
+}
+
+// Close shuts down the REST and websocket servers.
+func (s *Server) Close() {
+	s.DropWebsockets()
+	s.ws.Close()
+	s.rest.Close()
+}
+
+// Host returns the hostname the REST API is served on.
+func (s *Server) Host() string {
+	u, _ := url.Parse(s.rest.URL)
+	return u.Hostname()
+}
+
+// Port returns the port the REST API is served on.
+func (s *Server) Port() int {
+	u, _ := url.Parse(s.rest.URL)
+	port, _ := strconv.Atoi(u.Port())
+	return port
+}
+
+// Client returns a deconz client which talks to this server using DefaultAPIKey.
+func (s *Server) Client() *deconz.Client {
+	return deconz.NewClient(s.rest.Client(), s.Host(), s.Port(), DefaultAPIKey)
+}
+
+// PressLinkButton unlocks the gateway so the next request to create an API key succeeds.
+func (s *Server) PressLinkButton() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.linkButton = true
+	s.config["linkbutton"] = true
+}
+
+// AddLight adds a light to the gateway without announcing it, and returns its ID.
+// The state fields the light can't support are removed, so requests for them are rejected like the gateway would:
+// ct is removed if CTMax isn't set, xy, hue and sat are removed if XY isn't set, and bri is removed for on/off devices.
+// Window coverings are added by setting Lift, and optionally Tilt, in the state.
+func (s *Server) AddLight(light deconz.Light) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addResource("lights", lightObject(light))
+}
+
+// AddGroup adds a group to the gateway without announcing it, and returns its ID.
+func (s *Server) AddGroup(group deconz.Group) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj := toObject(group)
+	delete(obj, "scenes")
+	if action := subObject(obj, "action"); action["alert"] == nil {
+		action["alert"] = "none"
+	}
+	id := s.addResource("groups", obj)
+	obj["id"] = id
+	return id
+}
+
+// AddSensor adds a sensor to the gateway without announcing it, and returns its ID.
+func (s *Server) AddSensor(sensor deconz.Sensor) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addResource("sensors", toObject(sensor.SensorMetadata))
+}
+
+// JoinLight simulates a light joining the network: it is added, announced on the websocket
+// and, if a search is running, reported as a new light.
+func (s *Server) JoinLight(light deconz.Light) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj := lightObject(light)
+	id := s.addResource("lights", obj)
+	s.recordFound("lights", id)
+	s.broadcast(object{"t": "event", "e": "added", "r": "lights", "id": id, "uniqueid": obj["uniqueid"], "light": obj})
+	return id
+}
+
+// JoinSensor simulates a sensor joining the network: it is added, announced on the websocket
+// and, if a search is running, reported as a new sensor.
+func (s *Server) JoinSensor(sensor deconz.Sensor) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj := toObject(sensor.SensorMetadata)
+	id := s.addResource("sensors", obj)
+	s.recordFound("sensors", id)
+	s.broadcast(object{"t": "event", "e": "added", "r": "sensors", "id": id, "uniqueid": obj["uniqueid"], "sensor": obj})
+	return id
+}
+
+// RemoveLight deletes a light and announces the removal on the websocket.
+func (s *Server) RemoveLight(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteResource("lights", id)
+}
+
+// RemoveSensor deletes a sensor and announces the removal on the websocket.
+func (s *Server) RemoveSensor(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteResource("sensors", id)
+}
+
+// UpdateLightState simulates a light reporting a change, i.e. being switched on by hand.
+// The fields are merged into the light state and announced on the websocket.
+func (s *Server) UpdateLightState(id string, state map[string]interface{}) bool {
+	return s.updateDevice("lights", id, "state", state)
+}
+
+// UpdateSensorState simulates a sensor reporting a change, i.e. a door being opened.
+// The fields are merged into the sensor state, lastupdated is set, and the change is announced on the websocket.
+func (s *Server) UpdateSensorState(id string, state map[string]interface{}) bool {
+	withTime := object{"lastupdated": timestamp(time.Now())}
+	for k, v := range state {
+		withTime[k] = v
+	}
+	return s.updateDevice("sensors", id, "state", withTime)
+}
+
+// UpdateSensorConfig simulates a sensor reporting a configuration change, i.e. a new battery level.
+// The fields are merged into the sensor config and announced on the websocket.
+func (s *Server) UpdateSensorConfig(id string, config map[string]interface{}) bool {
+	return s.updateDevice("sensors", id, "config", config)
+}
+
+// Broadcast sends an arbitrary message to every connected websocket client.
+func (s *Server) Broadcast(msg interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.broadcast(msg)
+}
+
+// DropWebsockets closes every websocket connection, simulating a network interruption.
+// Clients are free to reconnect.
+func (s *Server) DropWebsockets() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+		delete(s.conns, conn)
+	}
+}
+
+func (s *Server) updateDevice(collection, id, field string, values map[string]interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj, ok := s.resources[collection][id]
+	if !ok {
+		return false
+	}
+
+	target := subObject(obj, field)
+	changed := object{}
+	for k, v := range values {
+		target[k] = normalize(v)
+		changed[k] = target[k]
+	}
+
+	s.broadcast(object{"t": "event", "e": "changed", "r": collection, "id": id, "uniqueid": obj["uniqueid"], field: changed})
+	return true
+}
+
+func (s *Server) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.conns[conn] = true
+	s.mu.Unlock()
+
+	// Nothing is expected from the client; reading detects when it goes away.
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+	conn.Close()
+}
+
+// broadcast must be called with the lock held, which also serializes the writes to each connection.
+func (s *Server) broadcast(msg interface{}) {
+	for conn := range s.conns {
+		if err := conn.WriteJSON(msg); err != nil {
+			conn.Close()
+			delete(s.conns, conn)
+		}
+	}
+}
+
+func (s *Server) addResource(collection string, obj object) string {
+	s.nextID[collection]++
+	id := strconv.Itoa(s.nextID[collection])
+
+	if _, ok := obj["etag"]; !ok {
+		obj["etag"] = etag()
+	}
+	s.resources[collection][id] = obj
+	return id
+}
+
+func (s *Server) deleteResource(collection, id string) bool {
+	obj, ok := s.resources[collection][id]
+	if !ok {
+		return false
+	}
+
+	delete(s.resources[collection], id)
+	if collection == "groups" {
+		delete(s.scenes, id)
+	}
+	if collection == "lights" || collection == "sensors" || collection == "groups" {
+		s.broadcast(object{"t": "event", "e": "deleted", "r": collection, "id": id, "uniqueid": obj["uniqueid"]})
+	}
+	return true
+}
+
+func (s *Server) recordFound(collection, id string) {
+	if srch, ok := s.searches[collection]; ok && time.Since(srch.started) < searchDuration {
+		srch.found = append(srch.found, id)
+	}
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// errorStatus contains the HTTP status the gateway uses for each error type.
+var errorStatus = map[int]int{
+	1:   http.StatusForbidden,
+	2:   http.StatusBadRequest,
+	3:   http.StatusNotFound,
+	4:   http.StatusMethodNotAllowed,
+	5:   http.StatusBadRequest,
+	6:   http.StatusBadRequest,
+	7:   http.StatusBadRequest,
+	8:   http.StatusForbidden,
+	101: http.StatusForbidden,
+}
+
+func (s *Server) writeError(w http.ResponseWriter, errType int, address, description string) {
+	status, ok := errorStatus[errType]
+	if !ok {
+		status = http.StatusBadRequest
+	}
+	s.writeJSON(w, status, []object{errorEntry(errType, address, description)})
+}
+
+// writeResults writes a list of success and error entries, using the status of the first error if there are no successes.
+// An empty list is written as [] rather than null, as the gateway does.
+func (s *Server) writeResults(w http.ResponseWriter, entries []object) {
+	if entries == nil {
+		entries = []object{}
+	}
+	status := http.StatusOK
+	if len(entries) > 0 {
+		if errEntry, ok := entries[0]["error"].(object); ok && len(successes(entries)) < 1 {
+			if errStatus, ok := errorStatus[errEntry["type"].(int)]; ok {
+				status = errStatus
+			}
+		}
+	}
+	s.writeJSON(w, status, entries)
+}
+
+func successes(entries []object) []object {
+	var ret []object
+	for _, entry := range entries {
+		if _, ok := entry["success"]; ok {
+			ret = append(ret, entry)
+		}
+	}
+	return ret
+}
+
+func successEntry(address string, value interface{}) object {
+	return object{"success": object{address: value}}
+}
+
+func errorEntry(errType int, address, description string) object {
+	return object{"error": object{"type": errType, "address": address, "description": description}}
+}
+
+// toObject converts one of the deconz types into the generic form used for storage.
+// The ID field is dropped as the gateway only reports it through the resource path.
+func toObject(v interface{}) object {
+	b, _ := json.Marshal(v)
+	obj := object{}
+	json.Unmarshal(b, &obj)
+	delete(obj, "ID")
+	return obj
+}
+
+// lightObject converts a light into the generic form, keeping only the state fields the light supports.
+func lightObject(light deconz.Light) object {
+	obj := toObject(light)
+	state := subObject(obj, "state")
+
+	if light.CTMax < 1 {
+		delete(state, "ct")
+		delete(obj, "ctmin")
+		delete(obj, "ctmax")
+	}
+	if light.State.XY == nil {
+		delete(state, "xy")
+		delete(state, "hue")
+		delete(state, "sat")
+	}
+	if len(light.State.ColorMode) < 1 {
+		delete(state, "colormode")
+	}
+	if len(light.State.Effect) < 1 {
+		delete(state, "effect")
+	}
+	if strings.HasPrefix(light.Type, "On/Off") || light.Type == "Smart plug" {
+		delete(state, "bri")
+	}
+	if light.State.Lift != nil && light.State.Open == nil {
+		state["open"] = *light.State.Lift < 100
+	}
+	return obj
+}
+
+// normalize converts a value into the form it would have after a JSON round trip, so stored values compare consistently.
+func normalize(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var ret interface{}
+	json.Unmarshal(b, &ret)
+	return ret
+}
+
+// subObject returns the named nested object, creating it if it doesn't exist.
+func subObject(obj object, field string) object {
+	switch sub := obj[field].(type) {
+	case object:
+		return sub
+	case map[string]interface{}:
+		obj[field] = object(sub)
+		return object(sub)
+	}
+	sub := object{}
+	obj[field] = sub
+	return sub
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedIDs returns the resource IDs in numeric order.
+func sortedIDs(m map[string]object) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+	return ids
+}
+
+func timestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000")
+}
+
+var etagCounter int
+var etagMu sync.Mutex
+
+func etag() string {
+	etagMu.Lock()
+	defer etagMu.Unlock()
+
+	etagCounter++
+	return strconv.FormatInt(time.Now().UnixNano()+int64(etagCounter), 16)
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
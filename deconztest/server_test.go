@@ -0,0 +1,177 @@
+package deconztest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rmrobinson/deconz-go"
+)
+
+// dialWebsocket connects to the websocket and waits until the server is sending to the connection.
+func dialWebsocket(t *testing.T, s *Server) *websocket.Conn {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.ws.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dialing the websocket returned error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		registered := len(s.conns) > 0
+		s.mu.Unlock()
+		if registered {
+			return conn
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the server didn't register the websocket connection")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// readEvent reads the next websocket message.
+func readEvent(t *testing.T, conn *websocket.Conn) map[string]interface{} {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	event := map[string]interface{}{}
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("reading the websocket returned error: %v", err)
+	}
+	return event
+}
+
+func TestAddLightRemovesUnsupportedState(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	plugID := s.AddLight(deconz.Light{Name: "Plug", Type: "On/Off plug-in unit", UniqueID: "00:11:22:33:44:55:66:77-01"})
+	coverID := s.AddLight(deconz.Light{Name: "Blind", Type: "Window covering device", UniqueID: "00:11:22:33:44:55:66:88-01", State: deconz.LightState{Lift: deconz.Int(100)}})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := subObject(s.resources["lights"][plugID], "state")
+	for _, k := range []string{"bri", "ct", "xy", "hue", "sat", "colormode", "effect"} {
+		if _, ok := state[k]; ok {
+			t.Errorf("on/off plug state has %s, want it removed", k)
+		}
+	}
+	if _, ok := state["on"]; !ok {
+		t.Errorf("on/off plug state = %v, want on kept", state)
+	}
+
+	// The open state of a window covering is derived from the lift if it isn't set
+	if state := subObject(s.resources["lights"][coverID], "state"); state["open"] != false {
+		t.Errorf("closed window covering state = %v, want open false", state)
+	}
+}
+
+func TestSearchReportsJoinedDevices(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := s.Client()
+	ctx := context.Background()
+
+	// Devices which join without a search running aren't reported
+	s.JoinLight(deconz.Light{Name: "Hall", Type: "Dimmable light", UniqueID: "00:11:22:33:44:55:66:77-01"})
+
+	newLights, err := client.GetNewLights(ctx)
+	if err != nil {
+		t.Fatalf("GetNewLights returned error: %v", err)
+	}
+	if newLights.LastScan != "none" || len(newLights.Names) != 0 {
+		t.Errorf("GetNewLights before a search = %+v, want no scan", newLights)
+	}
+
+	if err := client.SearchLights(ctx); err != nil {
+		t.Fatalf("SearchLights returned error: %v", err)
+	}
+	id := s.JoinLight(deconz.Light{Name: "Kitchen", Type: "Dimmable light", UniqueID: "00:11:22:33:44:55:66:88-01"})
+	s.JoinSensor(deconz.Sensor{})
+
+	newLights, err = client.GetNewLights(ctx)
+	if err != nil {
+		t.Fatalf("GetNewLights returned error: %v", err)
+	}
+	if newLights.LastScan != "active" || len(newLights.Names) != 1 || newLights.Names[id] != "Kitchen" {
+		t.Errorf("GetNewLights during a search = %+v, want only the kitchen light", newLights)
+	}
+
+	newSensors, err := client.GetNewSensors(ctx)
+	if err != nil {
+		t.Fatalf("GetNewSensors returned error: %v", err)
+	}
+	if newSensors.LastScan != "none" || len(newSensors.Names) != 0 {
+		t.Errorf("GetNewSensors without a sensor search = %+v, want no scan", newSensors)
+	}
+}
+
+func TestWebsocketEvents(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	conn := dialWebsocket(t, s)
+
+	sensor := deconz.Sensor{}
+	sensor.Name = "Front door"
+	sensor.Type = "ZHAOpenClose"
+	sensor.UniqueID = "00:11:22:33:44:55:66:aa-01-0006"
+	sensor.StateRaw = json.RawMessage(`{"open":false,"lastupdated":"none"}`)
+	id := s.JoinSensor(sensor)
+
+	event := readEvent(t, conn)
+	if event["e"] != "added" || event["r"] != "sensors" || event["id"] != id || event["sensor"] == nil {
+		t.Errorf("join event = %v, want the sensor added", event)
+	}
+
+	if !s.UpdateSensorState(id, map[string]interface{}{"open": true}) {
+		t.Fatal("UpdateSensorState returned false for an existing sensor")
+	}
+	event = readEvent(t, conn)
+	state, _ := event["state"].(map[string]interface{})
+	if event["e"] != "changed" || event["uniqueid"] != sensor.UniqueID || state["open"] != true || state["lastupdated"] == nil {
+		t.Errorf("state event = %v, want open with the update time", event)
+	}
+
+	if s.UpdateSensorState("9", map[string]interface{}{"open": true}) {
+		t.Error("UpdateSensorState returned true for an unknown sensor")
+	}
+
+	s.RemoveSensor(id)
+	if event = readEvent(t, conn); event["e"] != "deleted" || event["id"] != id {
+		t.Errorf("remove event = %v, want the sensor deleted", event)
+	}
+
+	s.DropWebsockets()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("reading after DropWebsockets succeeded, want the connection closed")
+	}
+}
+
+func TestClientUsesServer(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	state, err := s.Client().GetGatewayState(context.Background())
+	if err != nil {
+		t.Fatalf("GetGatewayState returned error: %v", err)
+	}
+	if state.Name != "deconztest" || state.WebsocketPort < 1 {
+		t.Errorf("GetGatewayState = %+v, want the server config", state)
+	}
+
+	// Requests with another key are rejected
+	other := deconz.NewClient(http.DefaultClient, s.Host(), s.Port(), "other")
+	if _, err := other.GetGatewayState(context.Background()); !deconz.IsGatewayError(err) {
+		t.Errorf("GetGatewayState with an unknown key returned %v, want a gateway error", err)
+	}
+}
@@ -0,0 +1,337 @@
+package deconz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDiscoveryPollInterval = 5 * time.Second
+	// maxPermitJoin is the longest time, in seconds, the network can be opened for; 255 opens it indefinitely.
+	maxPermitJoin = 254
+	// closeNetworkTimeout bounds the request which closes the network once discovery ends.
+	closeNetworkTimeout = 5 * time.Second
+)
+
+// SearchLights starts a search for new lights; the gateway searches for about a minute.
+// The lights found are available from GetNewLights.
+func (c *Client) SearchLights(ctx context.Context) error {
+	_, err := c.post(ctx, "lights", nil)
+	return err
+}
+
+// GetNewLights retrieves the lights found by the last search.
+func (c *Client) GetNewLights(ctx context.Context) (*NewDevicesResponse, error) {
+	resp := &NewDevicesResponse{}
+
+	err := c.get(ctx, "lights/new", resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// SearchSensors starts a search for new sensors; the gateway searches for about a minute.
+// The sensors found are available from GetNewSensors.
+func (c *Client) SearchSensors(ctx context.Context) error {
+	_, err := c.post(ctx, "sensors", nil)
+	return err
+}
+
+// GetNewSensors retrieves the sensors found by the last search.
+func (c *Client) GetNewSensors(ctx context.Context) (*NewDevicesResponse, error) {
+	resp := &NewDevicesResponse{}
+
+	err := c.get(ctx, "sensors/new", resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// NewDevicesResponse contains the devices found by the last search.
+type NewDevicesResponse struct {
+	// LastScan is 'active' while the search is running, 'none' if there hasn't been one, or the time it finished
+	LastScan string
+	// Names contains the name of each device found, keyed by ID
+	Names map[string]string
+}
+
+// Active checks whether the search is still running.
+func (r *NewDevicesResponse) Active() bool {
+	return r.LastScan == "active"
+}
+
+// UnmarshalJSON decodes the response, where the devices found are listed alongside the 'lastscan' field.
+func (r *NewDevicesResponse) UnmarshalJSON(b []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	r.LastScan = ""
+	r.Names = map[string]string{}
+	for k, v := range fields {
+		if k == "lastscan" {
+			if err := json.Unmarshal(v, &r.LastScan); err != nil {
+				return err
+			}
+			continue
+		}
+
+		device := struct {
+			Name string `json:"name"`
+		}{}
+		if err := json.Unmarshal(v, &device); err != nil {
+			return err
+		}
+		r.Names[k] = device.Name
+	}
+
+	return nil
+}
+
+// DiscoveredDevice contains a device which joined the network during discovery; only one of the fields is set.
+type DiscoveredDevice struct {
+	Light  *Light
+	Sensor *Sensor
+}
+
+// key identifies the device by its resource path, as lights and sensors are numbered separately.
+func (dd *DiscoveredDevice) key() string {
+	if dd.Light != nil {
+		return "lights/" + dd.Light.ID
+	}
+	return "sensors/" + dd.Sensor.ID
+}
+
+// Discovery pairs new devices: it opens the network, searches for new lights and sensors,
+// and reports each device which joins, whether it is seen through the search results or the websocket.
+type Discovery struct {
+	client *Client
+
+	// Websocket is used to watch for devices being added; if nil, only the search results are polled.
+	Websocket *WebsocketClient
+	// PollInterval is the time between requests for the search results; the default is used if it isn't positive.
+	PollInterval time.Duration
+
+	// OnError is called, if set, when the search results or a new device could not be retrieved.
+	// Discovery continues in this case.
+	OnError func(error)
+}
+
+// NewDiscovery creates a new discovery which uses the supplied API client, and a websocket client created from it.
+func NewDiscovery(c *Client) *Discovery {
+	return &Discovery{
+		client:       c,
+		Websocket:    NewWebsocketClient(c),
+		PollInterval: defaultDiscoveryPollInterval,
+	}
+}
+
+// Run opens the network for the supplied duration, of at most 254 seconds, and calls the handler for every device which joins.
+// Each device is reported once. It blocks until the duration has passed or the context is cancelled, and closes the network before returning.
+// The context error is returned if it was cancelled. A duration out of range is an ErrInvalidRequest;
+// errors from opening the network or starting the searches are returned as reported.
+func (d *Discovery) Run(ctx context.Context, duration time.Duration, handler func(*DiscoveredDevice)) error {
+	runCtx, cancel, err := d.start(ctx, duration)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer d.closeNetwork()
+
+	d.watch(runCtx, handler)
+	return ctx.Err()
+}
+
+// Listen opens the network and starts the searches, then returns a channel the devices which join are delivered on.
+// The channel is closed once the duration has passed or the context is cancelled, after the network has been closed.
+func (d *Discovery) Listen(ctx context.Context, duration time.Duration) (<-chan *DiscoveredDevice, error) {
+	runCtx, cancel, err := d.start(ctx, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make(chan *DiscoveredDevice)
+
+	go func() {
+		defer close(devices)
+		defer cancel()
+		defer d.closeNetwork()
+
+		d.watch(runCtx, func(device *DiscoveredDevice) {
+			select {
+			case devices <- device:
+			case <-runCtx.Done():
+			}
+		})
+	}()
+
+	return devices, nil
+}
+
+// start opens the network and starts the searches for new lights and sensors.
+// The returned context expires when the network closes; the network is closed again if the searches can't be started.
+func (d *Discovery) start(ctx context.Context, duration time.Duration) (context.Context, context.CancelFunc, error) {
+	seconds := int(duration / time.Second)
+	if seconds < 1 || seconds > maxPermitJoin {
+		return nil, nil, fmt.Errorf("%w: discovery duration %s not in 1s..%ds", ErrInvalidRequest, duration, maxPermitJoin)
+	}
+
+	if _, err := d.client.SetGatewayConfig(ctx, &SetConfigRequest{PermitJoin: Int(seconds)}); err != nil {
+		return nil, nil, err
+	}
+
+	err := d.client.SearchLights(ctx)
+	if err == nil {
+		err = d.client.SearchSensors(ctx)
+	}
+	if err != nil {
+		d.closeNetwork()
+		return nil, nil, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, duration)
+	return runCtx, cancel, nil
+}
+
+// watch reports the devices seen on the websocket or in the search results until the context expires.
+// The handler is only called from this goroutine.
+func (d *Discovery) watch(ctx context.Context, handler func(*DiscoveredDevice)) {
+	added := make(chan *DiscoveredDevice)
+	if d.Websocket != nil {
+		go d.Websocket.Run(ctx, func(update *WebsocketUpdate) {
+			device := addedDevice(update)
+			if device == nil {
+				return
+			}
+
+			select {
+			case added <- device:
+			case <-ctx.Done():
+			}
+		})
+	}
+
+	seen := map[string]bool{}
+	report := func(device *DiscoveredDevice) {
+		key := device.key()
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		handler(device)
+	}
+
+	interval := d.PollInterval
+	if interval <= 0 {
+		interval = defaultDiscoveryPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Devices may join as soon as the network opens, so the results are checked before the first tick
+	for _, device := range d.poll(ctx, seen) {
+		report(device)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case device := <-added:
+			report(device)
+		case <-ticker.C:
+			for _, device := range d.poll(ctx, seen) {
+				report(device)
+			}
+		}
+	}
+}
+
+// poll retrieves the lights and sensors found by the searches which haven't been reported yet.
+func (d *Discovery) poll(ctx context.Context, seen map[string]bool) []*DiscoveredDevice {
+	var ret []*DiscoveredDevice
+	// Requests cut short by the end of discovery aren't reported as errors
+	fail := func(err error) {
+		if ctx.Err() == nil {
+			d.onError(err)
+		}
+	}
+
+	newLights, err := d.client.GetNewLights(ctx)
+	if err != nil {
+		fail(err)
+	} else {
+		for id := range newLights.Names {
+			if seen["lights/"+id] {
+				continue
+			}
+			light, err := d.client.GetLight(ctx, id)
+			if err != nil {
+				fail(err)
+				continue
+			}
+			ret = append(ret, &DiscoveredDevice{Light: light})
+		}
+	}
+
+	newSensors, err := d.client.GetNewSensors(ctx)
+	if err != nil {
+		fail(err)
+	} else {
+		for id := range newSensors.Names {
+			if seen["sensors/"+id] {
+				continue
+			}
+			sensor, err := d.client.GetSensor(ctx, id)
+			if err != nil {
+				fail(err)
+				continue
+			}
+			ret = append(ret, &DiscoveredDevice{Sensor: sensor})
+		}
+	}
+
+	return ret
+}
+
+// closeNetwork stops devices joining. It is called once the discovery context may already be cancelled, so uses its own.
+func (d *Discovery) closeNetwork() {
+	ctx, cancel := context.WithTimeout(context.Background(), closeNetworkTimeout)
+	defer cancel()
+
+	if _, err := d.client.SetGatewayConfig(ctx, &SetConfigRequest{PermitJoin: Int(0)}); err != nil {
+		d.onError(err)
+	}
+}
+
+func (d *Discovery) onError(err error) {
+	if d.OnError != nil && err != nil {
+		d.OnError(err)
+	}
+}
+
+// addedDevice returns the light or sensor added by a websocket update, or nil for other updates.
+func addedDevice(update *WebsocketUpdate) *DiscoveredDevice {
+	if update.Meta.Event != "added" {
+		return nil
+	}
+
+	if update.Light != nil {
+		update.Light.ID = update.Meta.ResourceID
+		return &DiscoveredDevice{Light: update.Light}
+	}
+	// CLIP sensors are created through the REST API rather than joining the network
+	if update.Sensor != nil && !strings.HasPrefix(update.Sensor.Type, "CLIP") {
+		update.Sensor.ID = update.Meta.ResourceID
+		return &DiscoveredDevice{Sensor: update.Sensor}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package deconz_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/deconz-go"
+	"github.com/rmrobinson/deconz-go/deconztest"
+)
+
+func TestDiscoveryDefaultPollInterval(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+
+	discovery := deconz.NewDiscovery(server.Client())
+	discovery.Websocket = nil
+	discovery.PollInterval = 0
+	discovery.OnError = func(err error) {
+		t.Errorf("discovery reported error: %v", err)
+	}
+
+	if err := discovery.Run(context.Background(), time.Second, func(*deconz.DiscoveredDevice) {}); err != nil {
+		t.Errorf("Run returned error: %v", err)
+	}
+}
+
+func TestDiscoveryListen(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+
+	discovery := deconz.NewDiscovery(server.Client())
+	discovery.PollInterval = 10 * time.Millisecond
+	discovery.OnError = func(err error) {
+		t.Errorf("discovery reported error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices, err := discovery.Listen(ctx, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Listen returned error: %v", err)
+	}
+
+	lightID := server.JoinLight(deconz.Light{Name: "Porch", Type: "Dimmable light", UniqueID: "00:11:22:33:44:55:66:99-01"})
+	sensor := deconz.Sensor{}
+	sensor.Name = "Front door"
+	sensor.Type = "ZHAOpenClose"
+	sensor.UniqueID = "00:11:22:33:44:55:66:aa-01-0006"
+	sensorID := server.JoinSensor(sensor)
+
+	// Each device is seen on the websocket and in the search results, but must only be reported once
+	seen := map[string]int{}
+	timeout := time.After(5 * time.Second)
+	for seen["light "+lightID] < 1 || seen["sensor "+sensorID] < 1 {
+		select {
+		case device := <-devices:
+			if device.Light != nil {
+				seen["light "+device.Light.ID]++
+			} else {
+				seen["sensor "+device.Sensor.ID]++
+			}
+		case <-timeout:
+			t.Fatalf("discovery reported %v, want light %s and sensor %s", seen, lightID, sensorID)
+		}
+	}
+
+	// Let a few more polls happen before closing, to catch duplicates
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	for device := range devices {
+		if device.Light != nil {
+			seen["light "+device.Light.ID]++
+		} else {
+			seen["sensor "+device.Sensor.ID]++
+		}
+	}
+	for device, count := range seen {
+		if count != 1 {
+			t.Errorf("%s reported %d times, want once", device, count)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package deconz
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The following errors are reported by the gateway and are matched against a ResponseError using errors.Is.
+var (
+	// ErrUnauthorized is returned if the API key is missing or not valid
+	ErrUnauthorized = errors.New("unauthorized user")
+	// ErrInvalidJSON is returned if the gateway could not parse the request body
+	ErrInvalidJSON = errors.New("body contains invalid JSON")
+	// ErrResourceNotAvailable is returned if the requested resource does not exist
+	ErrResourceNotAvailable = errors.New("resource not available")
+	// ErrMethodNotAvailable is returned if the resource does not support the request method
+	ErrMethodNotAvailable = errors.New("method not available for resource")
+	// ErrMissingParameters is returned if a required parameter was not supplied
+	ErrMissingParameters = errors.New("missing parameters in body")
+	// ErrParameterNotAvailable is returned if a supplied parameter is not supported by the resource
+	ErrParameterNotAvailable = errors.New("parameter not available")
+	// ErrInvalidValue is returned if a supplied parameter has a value the gateway will not accept
+	ErrInvalidValue = errors.New("invalid value for parameter")
+	// ErrParameterNotModifiable is returned if a supplied parameter is read only
+	ErrParameterNotModifiable = errors.New("parameter not modifiable")
+	// ErrTooManyItems is returned if a list in the request has more entries than allowed
+	ErrTooManyItems = errors.New("too many items in list")
+	// ErrDuplicateExists is returned if the resource being created already exists
+	ErrDuplicateExists = errors.New("duplicate exists")
+	// ErrLinkButtonNotPressed is returned when creating an API key while the gateway isn't unlocked
+	ErrLinkButtonNotPressed = errors.New("link button not pressed")
+	// ErrDeviceOff is returned if a state change is sent to a light which is currently off
+	ErrDeviceOff = errors.New("device is set to off")
+)
+
+var (
+	// ErrInvalidRequest is returned, without contacting the gateway, if a request contains values the gateway can't accept.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrUnsupportedByDevice is returned, without contacting the gateway, if a request uses a feature the device doesn't have.
+	ErrUnsupportedByDevice = errors.New("unsupported by device")
+	// ErrWrongResourceType is returned, without contacting the gateway further, if a resource isn't of the type an operation needs,
+	// such as reading the thermostat schedule of a sensor which isn't a thermostat.
+	ErrWrongResourceType = errors.New("wrong resource type")
+	// ErrInvalidSchedule is returned if a thermostat schedule can't be parsed; it is wrapped in a DecodeError if the gateway reported it.
+	ErrInvalidSchedule = errors.New("invalid thermostat schedule")
+)
+
+var responseErrorTypes = map[int]error{
+	1:   ErrUnauthorized,
+	2:   ErrInvalidJSON,
+	3:   ErrResourceNotAvailable,
+	4:   ErrMethodNotAvailable,
+	5:   ErrMissingParameters,
+	6:   ErrParameterNotAvailable,
+	7:   ErrInvalidValue,
+	8:   ErrParameterNotModifiable,
+	11:  ErrTooManyItems,
+	100: ErrDuplicateExists,
+	101: ErrLinkButtonNotPressed,
+	201: ErrDeviceOff,
+}
+
+// Is allows a ResponseError to be compared against the exported gateway errors using errors.Is.
+func (re ResponseError) Is(target error) bool {
+	sentinel, ok := responseErrorTypes[re.Type]
+	return ok && sentinel == target
+}
+
+// TransportError is returned when the gateway could not be reached, or the connection failed before a response was received.
+type TransportError struct {
+	Err error
+}
+
+// Error allows the transport error to be returned as an Error compatible type.
+func (te *TransportError) Error() string {
+	return fmt.Sprintf("deconz transport: %s", te.Err.Error())
+}
+
+// Unwrap returns the underlying network error.
+func (te *TransportError) Unwrap() error {
+	return te.Err
+}
+
+// DecodeError is returned when the gateway responded, but the response could not be understood.
+type DecodeError struct {
+	Err error
+}
+
+// Error allows the decode error to be returned as an Error compatible type.
+func (de *DecodeError) Error() string {
+	return fmt.Sprintf("deconz decode: %s", de.Err.Error())
+}
+
+// Unwrap returns the underlying decoding error.
+func (de *DecodeError) Unwrap() error {
+	return de.Err
+}
+
+// IsTransportError returns true if the error was caused by a failure to communicate with the gateway.
+func IsTransportError(err error) bool {
+	var te *TransportError
+	return errors.As(err, &te)
+}
+
+// IsDecodeError returns true if the error was caused by an unexpected response from the gateway.
+func IsDecodeError(err error) bool {
+	var de *DecodeError
+	return errors.As(err, &de)
+}
+
+// IsGatewayError returns true if the error was reported by the gateway itself.
+func IsGatewayError(err error) bool {
+	var re ResponseError
+	return errors.As(err, &re)
+}
+
+// IsUnauthorized returns true if the gateway rejected the API key.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsResourceNotAvailable returns true if the requested resource doesn't exist on the gateway.
+func IsResourceNotAvailable(err error) bool {
+	return errors.Is(err, ErrResourceNotAvailable)
+}
+
+// IsInvalidValue returns true if the gateway rejected a parameter value.
+func IsInvalidValue(err error) bool {
+	return errors.Is(err, ErrInvalidValue)
+}
+
+// IsLinkButtonNotPressed returns true if the gateway wasn't unlocked when requesting an API key.
+func IsLinkButtonNotPressed(err error) bool {
+	return errors.Is(err, ErrLinkButtonNotPressed)
+}
+
+// IsDeviceOff returns true if the gateway rejected a state change because the device is off.
+func IsDeviceOff(err error) bool {
+	return errors.Is(err, ErrDeviceOff)
+}
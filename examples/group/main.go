@@ -59,24 +59,24 @@ func main() {
 	}
 
 	if *setState {
-		req := &deconz.SetGroupStateRequest{
-			SetLightStateRequest: deconz.SetLightStateRequest{
-				On: *isOn,
-			},
-		}
+		lightReq := deconz.NewLightState().WithOn(*isOn)
 
 		if *hue > 0 {
-			req.Hue = *hue
+			lightReq.WithHue(*hue)
 		}
 		if *sat > 0 {
-			req.Saturation = *sat
+			lightReq.WithSaturation(*sat)
 		}
+		req := lightReq.ForGroup()
 
-		err := c.SetGroupState(context.Background(), *resourceID, req)
+		results, err := c.SetGroupState(context.Background(), *resourceID, req)
 		if err != nil {
 			fmt.Printf("error setting group %d: %s\n", *resourceID, err.Error())
 			return
 		}
+		for _, result := range results {
+			fmt.Printf("set %s to %v\n", result.Address, result.Value)
+		}
 
 		fmt.Printf("set complete\n")
 	}
@@ -91,7 +91,7 @@ func main() {
 			req.LightIDs = lights
 		}
 
-		err := c.SetGroupConfig(context.Background(), *resourceID, req)
+		_, err := c.SetGroupConfig(context.Background(), *resourceID, req)
 		if err != nil {
 			fmt.Printf("error setting group %d config: %s\n", *resourceID, err.Error())
 			return
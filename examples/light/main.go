@@ -42,22 +42,23 @@ func main() {
 	}
 
 	if *setState {
-		req := &deconz.SetLightStateRequest{
-			On: *isOn,
-		}
+		req := deconz.NewLightState().WithOn(*isOn)
 
 		if *hue > 0 {
-			req.Hue = *hue
+			req.WithHue(*hue)
 		}
 		if *sat > 0 {
-			req.Saturation = *sat
+			req.WithSaturation(*sat)
 		}
 
-		err := c.SetLightState(context.Background(), strconv.Itoa(*resourceID), req)
+		results, err := c.SetLightState(context.Background(), strconv.Itoa(*resourceID), req)
 		if err != nil {
 			fmt.Printf("error setting light %d: %s\n", *resourceID, err.Error())
 			return
 		}
+		for _, result := range results {
+			fmt.Printf("set %s to %v\n", result.Address, result.Value)
+		}
 		fmt.Printf("set complete\n")
 	}
 	if *setConfig {
@@ -65,7 +66,7 @@ func main() {
 			Name: *name,
 		}
 
-		err := c.SetLightConfig(context.Background(), strconv.Itoa(*resourceID), req)
+		_, err := c.SetLightConfig(context.Background(), strconv.Itoa(*resourceID), req)
 		if err != nil {
 			fmt.Printf("error setting light %d config: %s\n", *resourceID, err.Error())
 			return
@@ -5,12 +5,9 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
-	"net/url"
-	"strconv"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
-	"github.com/gorilla/websocket"
 	"github.com/rmrobinson/deconz-go"
 )
 
@@ -24,37 +21,15 @@ func main() {
 
 	c := deconz.NewClient(&http.Client{}, *host, *port, *apiKey)
 
-	gw, err := c.GetGatewayState(context.Background())
-	if err != nil {
-		fmt.Printf("err getting gateway\n")
-		return
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
 
-	wsu := url.URL{
-		Scheme: "ws",
-		Host:   *host + ":" + strconv.Itoa(gw.WebsocketPort),
+	wsc := deconz.NewWebsocketClient(c)
+	wsc.OnError = func(err error) {
+		fmt.Printf("err reading from websocket: %s\n", err.Error())
 	}
 
-	wsc, _, err := websocket.DefaultDialer.Dial(wsu.String(), nil)
-	if err != nil {
-		fmt.Printf("err creating websocket: %s\n", err.Error())
-		return
+	for msg := range wsc.Listen(ctx) {
+		spew.Dump(msg)
 	}
-	defer wsc.Close()
-
-	go func() {
-		for {
-			msg := &deconz.WebsocketUpdate{}
-			err := wsc.ReadJSON(msg)
-
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				fmt.Printf("err reading from websocket: %s\n", err.Error())
-				return
-			}
-
-			spew.Dump(msg)
-		}
-	}()
-
-	time.Sleep(time.Second * 30)
 }
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // CreateAPIKey attempts to generate an API key to use for subequent operations.
@@ -26,24 +27,24 @@ func (c *Client) CreateAPIKey(ctx context.Context, reqType *CreateAPIKeyRequest)
 
 	resp, err := c.httpClient.Do(r)
 	if err != nil {
-		return "", err
+		return "", &TransportError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	deconzResp := &Response{}
 	err = json.NewDecoder(resp.Body).Decode(deconzResp)
 	if err != nil {
-		return "", err
+		return "", &DecodeError{Err: err}
 	}
 
 	if len(*deconzResp) < 1 {
-		return "", ErrMalformedResponse
+		return "", &DecodeError{Err: ErrMalformedResponse}
 	}
 	if id, ok := (*deconzResp)[0].Success["username"]; ok {
 		if strID, ok := id.(string); ok {
 			return strID, nil
 		}
-		return "", errors.New("new user id not string")
+		return "", &DecodeError{Err: errors.New("new user id not string")}
 	}
 
 	return "", (*deconzResp)[0].Error
@@ -66,6 +67,50 @@ func (c *Client) GetGatewayState(ctx context.Context) (*GatewayState, error) {
 	return gwState, nil
 }
 
+// SetGatewayConfig updates the gateway configuration with the supplied fields.
+// Only the fields which are set on the request are sent to the gateway.
+func (c *Client) SetGatewayConfig(ctx context.Context, newConfig *SetConfigRequest) (UpdateResults, error) {
+	return c.put(ctx, "config", newConfig)
+}
+
+// GetFullState retrieves the complete state of the gateway in a single call.
+// This includes the config, lights, groups, sensors, rules, schedules and resource links.
+func (c *Client) GetFullState(ctx context.Context) (*GetGatewayResponse, error) {
+	fullState := &GetGatewayResponse{}
+
+	err := c.get(ctx, "", fullState)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, group := range fullState.Groups {
+		group.ID = id
+		fullState.Groups[id] = group
+	}
+	for id, light := range fullState.Lights {
+		light.ID = id
+		fullState.Lights[id] = light
+	}
+	for id, rule := range fullState.Rules {
+		rule.ID = id
+		fullState.Rules[id] = rule
+	}
+	for id, schedule := range fullState.Schedules {
+		schedule.ID = id
+		fullState.Schedules[id] = schedule
+	}
+	for id, sensor := range fullState.Sensors {
+		sensor.ID = id
+		fullState.Sensors[id] = sensor
+	}
+	for id, link := range fullState.ResourceLinks {
+		link.ID = id
+		fullState.ResourceLinks[id] = link
+	}
+
+	return fullState, nil
+}
+
 // GatewayState contains the current state of the gateway
 type GatewayState struct {
 	APIVersion          string              `json:"apiversion"`
@@ -81,9 +126,10 @@ type GatewayState struct {
 	WebsocketPort      int  `json:"websocketport"`
 	LinkButtonPressed  bool `json:"linkbutton"`
 
-	Name       string `json:"name"`
-	LocalTime  string `json:"localtime"`
-	UTCTime    string `json:"utc"`
+	Name string `json:"name"`
+	// LocalTime is in the gateway timezone, if it is known to this system
+	LocalTime  Time   `json:"localtime"`
+	UTCTime    Time   `json:"utc"`
 	TimeFormat string `json:"timeformat"`
 	Timezone   string `json:"timezone"`
 
@@ -93,6 +139,21 @@ type GatewayState struct {
 	Netmask   string `json:"netmask"`
 }
 
+// gatewayState has the fields, but not the methods, of GatewayState so it can be used for the default decoding.
+type gatewayState GatewayState
+
+// UnmarshalJSON decodes the gateway state, applying the gateway timezone to the local time.
+func (gs *GatewayState) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, (*gatewayState)(gs)); err != nil {
+		return err
+	}
+
+	if loc, err := time.LoadLocation(gs.Timezone); err == nil && len(gs.Timezone) > 0 {
+		gs.LocalTime = gs.LocalTime.inLocation(loc)
+	}
+	return nil
+}
+
 // SoftwareUpdateState contains the important data about the current software update profile of the gateway
 type SoftwareUpdateState struct {
 	Notify      bool   `json:"notify"`
@@ -103,12 +164,13 @@ type SoftwareUpdateState struct {
 
 // GetGatewayResponse contains the returned data from the full gateway API call
 type GetGatewayResponse struct {
-	GatewayState GatewayState `json:"config"`
-	//Groups map[string]Group `json:"groups`
-	Lights GetLightsResponse `json:"lights"`
-	// Rules map[string]Rule `json:"rules"`
-	// Schedules map[string]Schedule `json:"schedules"`
-	Sensors map[int]Sensor `json:"sensors"`
+	GatewayState  GatewayState             `json:"config"`
+	Groups        GetGroupsResponse        `json:"groups"`
+	Lights        GetLightsResponse        `json:"lights"`
+	ResourceLinks GetResourceLinksResponse `json:"resourcelinks"`
+	Rules         GetRulesResponse         `json:"rules"`
+	Schedules     GetSchedulesResponse     `json:"schedules"`
+	Sensors       GetSensorsResponse       `json:"sensors"`
 }
 
 // CreateAPIKeyRequest contains the fields which will be used to request an API key.
@@ -118,24 +180,26 @@ type CreateAPIKeyRequest struct {
 }
 
 // SetConfigRequest contains the set of possible gateway configuration parameters.
+// The boolean and numeric fields are pointers so that an explicit false or 0 can be sent;
+// a nil field is left unchanged on the gateway. The Bool and Int helpers can be used to fill them in.
 type SetConfigRequest struct {
 	Name        string `json:"name,omitempty"`
-	RFConnected bool   `json:"rfconnected,omitempty"`
+	RFConnected *bool  `json:"rfconnected,omitempty"`
 	// UpdateChannel can be set to one of stable, alpha, beta
 	UpdateChannel string `json:"updatechannel,omitempty"`
 	// PermitJoin when set to 0 indicates no Zigbee devices can join
 	// 255 means the network is open
 	// 1..254 represents the time in seconds the network will be open
 	// These values decrement automatically
-	PermitJoin int `json:"permitjoin,omitempty"`
+	PermitJoin *int `json:"permitjoin,omitempty"`
 	// GroupDelay contains the time between two group commands, in milliseconds
-	GroupDelay        int  `json:"groupdelay,omitempty"`
-	OTAUActive        bool `json:"otauactive,omitempty"`
-	GWDiscoveryActive bool `json:"discovery,omitempty"`
+	GroupDelay        *int  `json:"groupdelay,omitempty"`
+	OTAUActive        *bool `json:"otauactive,omitempty"`
+	GWDiscoveryActive *bool `json:"discovery,omitempty"`
 	// Unlock being set to a value > 0 (and less than 600, the max) indicates the number of seconds the gateway is open for pairing
-	Unlock int `json:"unlock,omitempty"`
+	Unlock *int `json:"unlock,omitempty"`
 	// ZigbeeChannel specifies one of 11, 15, 20 or 25 (the valid Zigbee channel numbers)
-	ZigbeeChannel int    `json:"zigbeechannel,omitempty"`
+	ZigbeeChannel *int   `json:"zigbeechannel,omitempty"`
 	Timezone      string `json:"timezone,omitempty"`
 	UTC           string `json:"utc,omitempty"`
 	// TimeFormat is specified as either 12h or 24h
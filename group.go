@@ -3,21 +3,22 @@ package deconz
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strconv"
 )
 
 // CreateGroup creates a new group on the gateway. The new ID is returned on success.
 func (c *Client) CreateGroup(ctx context.Context, req *CreateGroupRequest) (int, error) {
-	resp, err := c.post(ctx, "groups", req)
+	results, err := c.post(ctx, "groups", req)
 	if err != nil {
 		return 0, err
 	}
 
-	if len(*resp) < 1 {
+	if len(results) < 1 {
 		return 0, errors.New("new group missing success entry")
 	}
-	if id, ok := (*resp)[0].Success["id"]; ok {
-		if strID, ok := id.(string); ok {
+	if results[0].Address == "id" {
+		if strID, ok := results[0].Value.(string); ok {
 			return strconv.Atoi(strID)
 		}
 		return 0, errors.New("new group id not string")
@@ -50,13 +51,20 @@ func (c *Client) GetGroup(ctx context.Context, id int) (*Group, error) {
 	return group, nil
 }
 
-// SetGroupState specifies the new state of a group
-func (c *Client) SetGroupState(ctx context.Context, id int, newState *SetGroupStateRequest) error {
+// SetGroupState specifies the new state of a group.
+// The results contain each field the gateway applied or rejected; an UpdateError is returned if any were rejected.
+func (c *Client) SetGroupState(ctx context.Context, id int, newState *SetGroupStateRequest) (UpdateResults, error) {
+	if newState == nil {
+		return nil, fmt.Errorf("%w: no group state specified", ErrInvalidRequest)
+	}
+	if err := newState.Validate(); err != nil {
+		return nil, err
+	}
 	return c.put(ctx, "groups/"+strconv.Itoa(id)+"/action", newState)
 }
 
 // SetGroupConfig specifies the new config of a group
-func (c *Client) SetGroupConfig(ctx context.Context, id int, newConfig *SetGroupConfigRequest) error {
+func (c *Client) SetGroupConfig(ctx context.Context, id int, newConfig *SetGroupConfigRequest) (UpdateResults, error) {
 	return c.put(ctx, "groups/"+strconv.Itoa(id), newConfig)
 }
 
@@ -123,9 +131,16 @@ type SetGroupConfigRequest struct {
 }
 
 // SetGroupStateRequest sets the state of the specified group.
+// It can be built from a light state request using ForGroup.
 type SetGroupStateRequest struct {
 	SetLightStateRequest
 	// Toggle flips the state from on to off or vice versa.
 	// This superscedes the values set directly.
 	Toggle bool `json:"toggle,omitempty"`
 }
+
+// WithToggle sets the group to flip its lights from on to off or vice versa.
+func (r *SetGroupStateRequest) WithToggle() *SetGroupStateRequest {
+	r.Toggle = true
+	return r
+}
@@ -2,6 +2,9 @@ package deconz
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/rmrobinson/deconz-go/color"
 )
 
 // GetLights retrieves all the lights available on the gatway
@@ -13,6 +16,11 @@ func (c *Client) GetLights(ctx context.Context) (GetLightsResponse, error) {
 		return nil, err
 	}
 
+	for id, light := range lightsResp {
+		light.ID = id
+		lightsResp[id] = light
+	}
+
 	return lightsResp, nil
 }
 
@@ -25,16 +33,31 @@ func (c *Client) GetLight(ctx context.Context, id string) (*Light, error) {
 		return nil, err
 	}
 
+	light.ID = id
 	return light, nil
 }
 
-// SetLightState specifies the new state of a light
-func (c *Client) SetLightState(ctx context.Context, id string, newState *SetLightStateRequest) error {
+// SetLightState specifies the new state of a light.
+// The options, such as RejectUnsupported or ClampToCapabilities, are applied to the request in order before it is sent.
+// The results contain each field the gateway applied or rejected; an UpdateError is returned if any were rejected.
+func (c *Client) SetLightState(ctx context.Context, id string, newState *SetLightStateRequest, opts ...LightStateOption) (UpdateResults, error) {
+	if newState == nil {
+		return nil, fmt.Errorf("%w: no light state specified", ErrInvalidRequest)
+	}
+	for _, opt := range opts {
+		var err error
+		if newState, err = opt(newState); err != nil {
+			return nil, err
+		}
+	}
+	if err := newState.Validate(); err != nil {
+		return nil, err
+	}
 	return c.put(ctx, "lights/"+id+"/state", newState)
 }
 
 // SetLightConfig specifies the new config of a light
-func (c *Client) SetLightConfig(ctx context.Context, id string, newConfig *SetLightConfigRequest) error {
+func (c *Client) SetLightConfig(ctx context.Context, id string, newConfig *SetLightConfigRequest) (UpdateResults, error) {
 	return c.put(ctx, "lights/"+id, newConfig)
 }
 
@@ -57,19 +80,31 @@ func (c *Client) DeleteLightScenes(ctx context.Context, id string) error {
 type Light struct {
 	// ID contains the gateway-specified ID; could change.
 	// Exists only for accessing by path; dedup using UniqueID instead
-	ID              string
-	CTMax           int        `json:"ctmax"`
-	CTMin           int        `json:"ctmin"`
-	LastAnnounced   string     `json:"lastannounced"`
-	LastSeen        string     `json:"lastseen"`
-	ETag            string     `json:"etag"`
-	Manufacturer    string     `json:"manufacturer"`
-	Name            string     `json:"name"`
-	ModelID         string     `json:"modelid"`
-	SoftwareVersion string     `json:"swversion"`
-	Type            string     `json:"type"`
-	State           LightState `json:"state"`
-	UniqueID        string     `json:"uniqueid"`
+	ID    string
+	CTMax int `json:"ctmax"`
+	CTMin int `json:"ctmin"`
+	// ReportedCapabilities is only included by newer gateway versions; use Capabilities to inspect the light
+	ReportedCapabilities *ReportedLightCapabilities `json:"capabilities,omitempty"`
+	LastAnnounced        Time                       `json:"lastannounced"`
+	LastSeen             Time                       `json:"lastseen"`
+	ETag                 string                     `json:"etag"`
+	Manufacturer         string                     `json:"manufacturer"`
+	Name                 string                     `json:"name"`
+	ModelID              string                     `json:"modelid"`
+	SoftwareVersion      string                     `json:"swversion"`
+	Type                 string                     `json:"type"`
+	State                LightState                 `json:"state"`
+	UniqueID             string                     `json:"uniqueid"`
+}
+
+// Gamut returns the range of colours the light can reproduce, based on the reported capabilities or its model.
+// The gamut is unrestricted if the model isn't known.
+func (l *Light) Gamut() color.Gamut {
+	if gamut := l.reportedGamut(); !gamut.IsZero() {
+		return gamut
+	}
+	gamut, _ := color.GamutForModel(l.ModelID)
+	return gamut
 }
 
 // LightState contains the specific, controllable fields of a light.
@@ -84,6 +119,27 @@ type LightState struct {
 	ColorMode  string    `json:"colormode"`
 	Effect     string    `json:"effect"`
 	Reachable  bool      `json:"reachable"`
+
+	// The following are only reported by window coverings; use WindowCovering to read them
+	Open *bool `json:"open,omitempty"`
+	Lift *int  `json:"lift,omitempty"`
+	Tilt *int  `json:"tilt,omitempty"`
+}
+
+// Color returns the colour the light is set to as sRGB, based on the active colour mode.
+// The on/off state of the light is not taken into account.
+func (ls LightState) Color() color.RGB {
+	switch {
+	case ls.ColorMode == "xy" && len(ls.XY) == 2:
+		return color.FromXY(color.XY{X: ls.XY[0], Y: ls.XY[1]}, ls.Brightness, color.Gamut{})
+	case ls.ColorMode == "hs":
+		return color.FromHueSat(ls.Hue, ls.Saturation, ls.Brightness)
+	case ls.ColorMode == "ct" && ls.CT > 0:
+		return color.FromMired(ls.CT, ls.Brightness)
+	}
+
+	// Lights without colour support are shown as white at their brightness
+	return color.FromHueSat(0, 0, ls.Brightness)
 }
 
 // GetLightsResponse contains the result of all active lights.
@@ -91,12 +147,14 @@ type GetLightsResponse map[string]Light
 
 // SetLightStateRequest lets a user update certain properties of the light.
 // These are directly changing the active light and what it is showing.
+// Fields which are nil are not sent, so only the specified properties change;
+// NewLightState and the With methods can be used to build a request without handling the pointers directly.
 type SetLightStateRequest struct {
-	On         bool      `json:"on"`
-	Brightness int       `json:"bri,omitempty"`
-	Hue        int       `json:"hue,omitempty"`
-	Saturation int       `json:"sat,omitempty"`
-	CT         int       `json:"ct,omitempty"`
+	On         *bool     `json:"on,omitempty"`
+	Brightness *int      `json:"bri,omitempty"`
+	Hue        *int      `json:"hue,omitempty"`
+	Saturation *int      `json:"sat,omitempty"`
+	CT         *int      `json:"ct,omitempty"`
 	XY         []float64 `json:"xy,omitempty"`
 	Alert      string    `json:"alert,omitempty"`
 	// Effect contains the light effect to apply. Either 'none' or 'colorloop'
@@ -104,9 +162,238 @@ type SetLightStateRequest struct {
 	// ColorLoopSpeed contains the speed of a colorloop.
 	// 1 is very fast, 255 is very slow.
 	// This is only read if the 'colorloop' effect is specifed
-	ColorLoopSpeed int `json:"colorloopspeed,omitempty"`
+	ColorLoopSpeed *int `json:"colorloopspeed,omitempty"`
 	// TransitionTime is represented in 1/10th of a second between states
-	TransitionTime int `json:"transitiontime,omitempty"`
+	TransitionTime *int `json:"transitiontime,omitempty"`
+
+	// The following fields change the current value by the given amount, rather than replacing it.
+	// They can't be combined with their absolute counterparts.
+	// BrightnessIncrement is from -254 to 254
+	BrightnessIncrement *int `json:"bri_inc,omitempty"`
+	// HueIncrement is from -65534 to 65534
+	HueIncrement *int `json:"hue_inc,omitempty"`
+	// SaturationIncrement is from -254 to 254
+	SaturationIncrement *int `json:"sat_inc,omitempty"`
+	// CTIncrement is from -65534 to 65534
+	CTIncrement *int `json:"ct_inc,omitempty"`
+	// XYIncrement contains the change to each of x and y, from -0.5 to 0.5
+	XYIncrement []float64 `json:"xy_inc,omitempty"`
+
+	// The following fields only apply to window coverings.
+	// Open fully opens or closes the covering
+	Open *bool `json:"open,omitempty"`
+	// Lift moves the covering to the given percentage closed, from 0 to 100
+	Lift *int `json:"lift,omitempty"`
+	// Tilt turns the slats to the given percentage, from 0 to 100
+	Tilt *int `json:"tilt,omitempty"`
+	// Stop halts the movement of the covering
+	Stop *bool `json:"stop,omitempty"`
+}
+
+// Validate checks the request for values the gateway will not accept.
+// It is called by SetLightState and SetGroupState before the request is sent.
+func (r *SetLightStateRequest) Validate() error {
+	if r == nil {
+		return fmt.Errorf("%w: no light state specified", ErrInvalidRequest)
+	}
+	if r.BrightnessIncrement != nil {
+		if r.Brightness != nil {
+			return fmt.Errorf("%w: bri and bri_inc can't both be set", ErrInvalidRequest)
+		}
+		if *r.BrightnessIncrement < -254 || *r.BrightnessIncrement > 254 {
+			return fmt.Errorf("%w: bri_inc %d not in -254..254", ErrInvalidRequest, *r.BrightnessIncrement)
+		}
+	}
+	if r.HueIncrement != nil {
+		if r.Hue != nil {
+			return fmt.Errorf("%w: hue and hue_inc can't both be set", ErrInvalidRequest)
+		}
+		if *r.HueIncrement < -65534 || *r.HueIncrement > 65534 {
+			return fmt.Errorf("%w: hue_inc %d not in -65534..65534", ErrInvalidRequest, *r.HueIncrement)
+		}
+	}
+	if r.SaturationIncrement != nil {
+		if r.Saturation != nil {
+			return fmt.Errorf("%w: sat and sat_inc can't both be set", ErrInvalidRequest)
+		}
+		if *r.SaturationIncrement < -254 || *r.SaturationIncrement > 254 {
+			return fmt.Errorf("%w: sat_inc %d not in -254..254", ErrInvalidRequest, *r.SaturationIncrement)
+		}
+	}
+	if r.CTIncrement != nil {
+		if r.CT != nil {
+			return fmt.Errorf("%w: ct and ct_inc can't both be set", ErrInvalidRequest)
+		}
+		if *r.CTIncrement < -65534 || *r.CTIncrement > 65534 {
+			return fmt.Errorf("%w: ct_inc %d not in -65534..65534", ErrInvalidRequest, *r.CTIncrement)
+		}
+	}
+	if r.XYIncrement != nil {
+		if r.XY != nil {
+			return fmt.Errorf("%w: xy and xy_inc can't both be set", ErrInvalidRequest)
+		}
+		if len(r.XYIncrement) != 2 {
+			return fmt.Errorf("%w: xy_inc must contain 2 values", ErrInvalidRequest)
+		}
+		for _, inc := range r.XYIncrement {
+			if inc < -0.5 || inc > 0.5 {
+				return fmt.Errorf("%w: xy_inc %v not in -0.5..0.5", ErrInvalidRequest, inc)
+			}
+		}
+	}
+	if r.Lift != nil && (*r.Lift < 0 || *r.Lift > 100) {
+		return fmt.Errorf("%w: lift %d not in 0..100", ErrInvalidRequest, *r.Lift)
+	}
+	if r.Tilt != nil && (*r.Tilt < 0 || *r.Tilt > 100) {
+		return fmt.Errorf("%w: tilt %d not in 0..100", ErrInvalidRequest, *r.Tilt)
+	}
+
+	return nil
+}
+
+// NewLightState creates an empty light state request; nothing is changed until one of the With methods is called.
+func NewLightState() *SetLightStateRequest {
+	return &SetLightStateRequest{}
+}
+
+// WithOn sets whether the light should be on or off.
+func (r *SetLightStateRequest) WithOn(on bool) *SetLightStateRequest {
+	r.On = &on
+	return r
+}
+
+// WithBrightness sets the brightness, from 0 to 255.
+func (r *SetLightStateRequest) WithBrightness(bri int) *SetLightStateRequest {
+	r.Brightness = &bri
+	return r
+}
+
+// WithHue sets the hue, from 0 to 65535.
+func (r *SetLightStateRequest) WithHue(hue int) *SetLightStateRequest {
+	r.Hue = &hue
+	return r
+}
+
+// WithSaturation sets the saturation, from 0 to 255.
+func (r *SetLightStateRequest) WithSaturation(sat int) *SetLightStateRequest {
+	r.Saturation = &sat
+	return r
+}
+
+// WithCT sets the colour temperature, in mireds.
+func (r *SetLightStateRequest) WithCT(ct int) *SetLightStateRequest {
+	r.CT = &ct
+	return r
+}
+
+// WithXY sets the colour as CIE xy coordinates, each from 0 to 1.
+func (r *SetLightStateRequest) WithXY(x, y float64) *SetLightStateRequest {
+	r.XY = []float64{x, y}
+	return r
+}
+
+// WithAlert sets the alert effect. Either 'none', 'select' or 'lselect'
+func (r *SetLightStateRequest) WithAlert(alert string) *SetLightStateRequest {
+	r.Alert = alert
+	return r
+}
+
+// WithEffect sets the light effect. Either 'none' or 'colorloop'
+func (r *SetLightStateRequest) WithEffect(effect string) *SetLightStateRequest {
+	r.Effect = effect
+	return r
+}
+
+// WithColorLoopSpeed sets the speed of a colorloop; 1 is very fast, 255 is very slow.
+func (r *SetLightStateRequest) WithColorLoopSpeed(speed int) *SetLightStateRequest {
+	r.ColorLoopSpeed = &speed
+	return r
+}
+
+// WithTransition sets the time taken to change to the new state, in 1/10th of a second.
+func (r *SetLightStateRequest) WithTransition(transitionTime int) *SetLightStateRequest {
+	r.TransitionTime = &transitionTime
+	return r
+}
+
+// WithRGB sets the colour and brightness of the light from an sRGB colour.
+// The colour is not restricted to a gamut; use WithColor to clip it to what the light can reproduce.
+func (r *SetLightStateRequest) WithRGB(red, green, blue uint8) *SetLightStateRequest {
+	return r.WithColor(color.RGB{R: red, G: green, B: blue}, color.Gamut{})
+}
+
+// WithColor sets the colour and brightness of the light from an sRGB colour, clipped to the supplied gamut.
+// The gamut of a light can be retrieved using Light.Gamut.
+func (r *SetLightStateRequest) WithColor(c color.RGB, gamut color.Gamut) *SetLightStateRequest {
+	xy, bri := c.XY(gamut)
+	return r.WithXY(xy.X, xy.Y).WithBrightness(bri)
+}
+
+// WithKelvin sets the colour temperature of the light, in Kelvin.
+func (r *SetLightStateRequest) WithKelvin(kelvin int) *SetLightStateRequest {
+	return r.WithCT(color.KelvinToMired(kelvin))
+}
+
+// WithBrightnessIncrement changes the brightness by the given amount, from -254 to 254.
+func (r *SetLightStateRequest) WithBrightnessIncrement(inc int) *SetLightStateRequest {
+	r.BrightnessIncrement = &inc
+	return r
+}
+
+// WithHueIncrement changes the hue by the given amount, from -65534 to 65534.
+func (r *SetLightStateRequest) WithHueIncrement(inc int) *SetLightStateRequest {
+	r.HueIncrement = &inc
+	return r
+}
+
+// WithSaturationIncrement changes the saturation by the given amount, from -254 to 254.
+func (r *SetLightStateRequest) WithSaturationIncrement(inc int) *SetLightStateRequest {
+	r.SaturationIncrement = &inc
+	return r
+}
+
+// WithCTIncrement changes the colour temperature by the given amount of mireds, from -65534 to 65534.
+func (r *SetLightStateRequest) WithCTIncrement(inc int) *SetLightStateRequest {
+	r.CTIncrement = &inc
+	return r
+}
+
+// WithXYIncrement changes the CIE xy coordinates by the given amounts, each from -0.5 to 0.5.
+func (r *SetLightStateRequest) WithXYIncrement(x, y float64) *SetLightStateRequest {
+	r.XYIncrement = []float64{x, y}
+	return r
+}
+
+// WithOpen sets whether a window covering should be fully opened or closed.
+func (r *SetLightStateRequest) WithOpen(open bool) *SetLightStateRequest {
+	r.Open = &open
+	return r
+}
+
+// WithLift sets how far a window covering should be closed, from 0 (open) to 100 (closed).
+func (r *SetLightStateRequest) WithLift(lift int) *SetLightStateRequest {
+	r.Lift = &lift
+	return r
+}
+
+// WithTilt sets the slat angle of a window covering, from 0 to 100.
+func (r *SetLightStateRequest) WithTilt(tilt int) *SetLightStateRequest {
+	r.Tilt = &tilt
+	return r
+}
+
+// WithStop halts a moving window covering.
+func (r *SetLightStateRequest) WithStop() *SetLightStateRequest {
+	stop := true
+	r.Stop = &stop
+	return r
+}
+
+// ForGroup converts the request into one which can be applied to a group.
+func (r *SetLightStateRequest) ForGroup() *SetGroupStateRequest {
+	return &SetGroupStateRequest{
+		SetLightStateRequest: *r,
+	}
 }
 
 // SetLightConfigRequest lets a user update certain properties of the light.
@@ -0,0 +1,56 @@
+package deconz_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rmrobinson/deconz-go"
+	"github.com/rmrobinson/deconz-go/deconztest"
+)
+
+func TestSetLightStatePartial(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	// A dimmable light has no colour temperature, so the gateway rejects that field and applies the others
+	id := server.AddLight(deconz.Light{Name: "Hall", Type: "Dimmable light", UniqueID: "00:11:22:33:44:55:66:77-01"})
+
+	results, err := client.SetLightState(ctx, id, deconz.NewLightState().WithOn(true).WithBrightness(100).WithCT(300))
+
+	var updateErr *deconz.UpdateError
+	if !errors.As(err, &updateErr) {
+		t.Fatalf("SetLightState returned %v, want an UpdateError", err)
+	}
+	if !errors.Is(err, deconz.ErrParameterNotAvailable) {
+		t.Errorf("SetLightState returned %v, want it to wrap ErrParameterNotAvailable", err)
+	}
+	if len(results) != 3 || len(results.Succeeded()) != 2 || len(results.Failed()) != 1 {
+		t.Fatalf("SetLightState results = %+v, want 2 applied and 1 rejected", results)
+	}
+	if failed := results.Failed()[0]; failed.Address != "/lights/"+id+"/state/ct" {
+		t.Errorf("rejected field = %s, want ct", failed.Address)
+	}
+	if len(updateErr.Results) != len(results) {
+		t.Errorf("UpdateError contains %d results, want all %d", len(updateErr.Results), len(results))
+	}
+
+	light, err := client.GetLight(ctx, id)
+	if err != nil {
+		t.Fatalf("GetLight returned error: %v", err)
+	}
+	if !light.State.On || light.State.Brightness != 100 {
+		t.Errorf("light state = %+v, want the applied fields set", light.State)
+	}
+}
+
+func TestSetLightStateNil(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+
+	if _, err := server.Client().SetLightState(context.Background(), "1", nil); !errors.Is(err, deconz.ErrInvalidRequest) {
+		t.Errorf("SetLightState with a nil request returned %v, want ErrInvalidRequest", err)
+	}
+}
@@ -0,0 +1,246 @@
+package deconz
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+)
+
+// Unit is the unit a measurement is expressed in.
+type Unit string
+
+// The units measurements are converted to.
+const (
+	UnitCelsius          Unit = "°C"
+	UnitFahrenheit       Unit = "°F"
+	UnitRelativeHumidity Unit = "%RH"
+	UnitHectopascal      Unit = "hPa"
+	UnitLux              Unit = "lx"
+	UnitWatt             Unit = "W"
+	UnitVolt             Unit = "V"
+	UnitAmpere           Unit = "A"
+	UnitKilowattHour     Unit = "kWh"
+	UnitPercent          Unit = "%"
+	UnitPartsPerMillion  Unit = "ppm"
+	UnitPartsPerBillion  Unit = "ppb"
+	UnitMicrogramsPerM3  Unit = "µg/m³"
+)
+
+// Quantity is the physical property a measurement is of.
+type Quantity string
+
+// The quantities reported by the sensors.
+const (
+	QuantityTemperature  Quantity = "temperature"
+	QuantityHumidity     Quantity = "humidity"
+	QuantityPressure     Quantity = "pressure"
+	QuantityIlluminance  Quantity = "illuminance"
+	QuantityPower        Quantity = "power"
+	QuantityVoltage      Quantity = "voltage"
+	QuantityCurrent      Quantity = "current"
+	QuantityEnergy       Quantity = "energy"
+	QuantityValve        Quantity = "valve"
+	QuantityBattery      Quantity = "battery"
+	QuantityAirQuality   Quantity = "airquality"
+	QuantityCO2          Quantity = "co2"
+	QuantityPM25         Quantity = "pm2.5"
+	QuantityFormaldehyde Quantity = "formaldehyde"
+	QuantityMoisture     Quantity = "moisture"
+)
+
+// Measurement contains a single value reported by a sensor, converted to its unit.
+type Measurement struct {
+	Quantity Quantity
+	Value    float64
+	Unit     Unit
+}
+
+// Celsius returns the temperature in degrees Celsius.
+func (t ZHATemperature) Celsius() float64 {
+	return fromCentiDegrees(t.Temperature)
+}
+
+// Fahrenheit returns the temperature in degrees Fahrenheit.
+func (t ZHATemperature) Fahrenheit() float64 {
+	return t.Celsius()*9/5 + 32
+}
+
+// RelativeHumidity returns the humidity as a percentage.
+func (h ZHAHumidity) RelativeHumidity() float64 {
+	return float64(h.Humidity) / 100
+}
+
+// Hectopascals returns the air pressure in hectopascals.
+func (p ZHAPressure) Hectopascals() float64 {
+	return float64(p.Pressure)
+}
+
+// Illuminance returns the light level in lux. The gateway reports it as 10000 * log10(lux) + 1.
+func (l ZHALightLevel) Illuminance() float64 {
+	if l.LightLevel < 1 {
+		return 0
+	}
+	return math.Pow(10, float64(l.LightLevel-1)/10000)
+}
+
+// PowerScale contains the factors the raw readings of a power monitor are multiplied by to convert them to units.
+type PowerScale struct {
+	Power   float64
+	Voltage float64
+	Current float64
+}
+
+// DefaultPowerScale is used for models without a specific scale; the gateway usually reports watts, volts and milliamperes.
+var DefaultPowerScale = PowerScale{
+	Power:   1,
+	Voltage: 1,
+	Current: 0.001,
+}
+
+// hundredthsPowerScale is used by models which report the voltage and current in hundredths of a volt and an ampere.
+var hundredthsPowerScale = PowerScale{
+	Power:   1,
+	Voltage: 0.01,
+	Current: 0.01,
+}
+
+var (
+	modelPowerScalesMu sync.RWMutex
+	// modelPowerScales contains the scale of the models which don't report in the default units
+	modelPowerScales = map[string]PowerScale{
+		// Develco smart plugs and relays report the voltage in hundredths of a volt
+		"SPLZB-131": {Power: 1, Voltage: 0.01, Current: 0.001},
+		"SPLZB-132": {Power: 1, Voltage: 0.01, Current: 0.001},
+		"SPLZB-134": {Power: 1, Voltage: 0.01, Current: 0.001},
+		"SMRZB-143": {Power: 1, Voltage: 0.01, Current: 0.001},
+		// The Heiman smart plug reports both the voltage and the current in hundredths
+		"SmartPlug": hundredthsPowerScale,
+	}
+)
+
+// PowerScaleForModel returns the scale of the power monitor model.
+// The default scale is returned, with false, if the model has no scale registered.
+func PowerScaleForModel(modelID string) (PowerScale, bool) {
+	modelPowerScalesMu.RLock()
+	defer modelPowerScalesMu.RUnlock()
+
+	scale, ok := modelPowerScales[modelID]
+	if !ok {
+		return DefaultPowerScale, false
+	}
+	return scale, true
+}
+
+// RegisterPowerScale sets the scale of a power monitor model which reports its readings in other units.
+func RegisterPowerScale(modelID string, scale PowerScale) {
+	modelPowerScalesMu.Lock()
+	defer modelPowerScalesMu.Unlock()
+
+	modelPowerScales[modelID] = scale
+}
+
+// Watts returns the power being drawn in watts; PowerScaleForModel returns the scale of the sensor model.
+func (p ZHAPower) Watts(scale PowerScale) float64 {
+	return float64(p.Power) * scale.Power
+}
+
+// Volts returns the supply voltage in volts; PowerScaleForModel returns the scale of the sensor model.
+func (p ZHAPower) Volts(scale PowerScale) float64 {
+	return float64(p.Voltage) * scale.Voltage
+}
+
+// Amperes returns the current being drawn in amperes; PowerScaleForModel returns the scale of the sensor model.
+func (p ZHAPower) Amperes(scale PowerScale) float64 {
+	return float64(p.Current) * scale.Current
+}
+
+// KilowattHours returns the total energy consumed in kilowatt hours. The gateway reports it in watt hours.
+func (c ZHAConsumption) KilowattHours() float64 {
+	return float64(c.Consumption) / 1000
+}
+
+// Watts returns the power being drawn in watts.
+func (c ZHAConsumption) Watts() float64 {
+	return float64(c.Power)
+}
+
+// Measurements returns every value the sensor reports, converted to its unit.
+// Temperatures are in degrees Celsius unless UnitFahrenheit is preferred, and power readings use the scale registered for the model.
+// Sensors which don't measure anything, such as switches, return only their battery level, if they have one.
+func (s *Sensor) Measurements(preferred ...Unit) []Measurement {
+	var ret []Measurement
+	add := func(quantity Quantity, value float64, unit Unit) {
+		ret = append(ret, Measurement{Quantity: quantity, Value: value, Unit: unit})
+	}
+
+	fahrenheit := false
+	for _, unit := range preferred {
+		fahrenheit = fahrenheit || unit == UnitFahrenheit
+	}
+	addTemperature := func(celsius float64) {
+		if fahrenheit {
+			add(QuantityTemperature, celsius*9/5+32, UnitFahrenheit)
+		} else {
+			add(QuantityTemperature, celsius, UnitCelsius)
+		}
+	}
+
+	if s.TemperatureState != nil {
+		addTemperature(s.TemperatureState.Celsius())
+	}
+	if s.HumidityState != nil {
+		add(QuantityHumidity, s.HumidityState.RelativeHumidity(), UnitRelativeHumidity)
+	}
+	if s.PressureState != nil {
+		add(QuantityPressure, s.PressureState.Hectopascals(), UnitHectopascal)
+	}
+	if s.LightLevelState != nil {
+		add(QuantityIlluminance, s.LightLevelState.Illuminance(), UnitLux)
+	}
+	if s.PowerState != nil {
+		scale, _ := PowerScaleForModel(s.ModelID)
+		add(QuantityPower, s.PowerState.Watts(scale), UnitWatt)
+		add(QuantityVoltage, s.PowerState.Volts(scale), UnitVolt)
+		add(QuantityCurrent, s.PowerState.Amperes(scale), UnitAmpere)
+	}
+	if s.ConsumptionState != nil {
+		add(QuantityEnergy, s.ConsumptionState.KilowattHours(), UnitKilowattHour)
+		add(QuantityPower, s.ConsumptionState.Watts(), UnitWatt)
+	}
+	if s.ThermostatState != nil {
+		addTemperature(s.ThermostatState.TemperatureCelsius())
+		add(QuantityValve, float64(s.ThermostatState.Valve), UnitPercent)
+	}
+	if s.AirQualityState != nil {
+		add(QuantityAirQuality, float64(s.AirQualityState.AirQualityPPB), UnitPartsPerBillion)
+	}
+	if s.CarbonDioxideState != nil {
+		add(QuantityCO2, s.CarbonDioxideState.MeasuredValue, UnitPartsPerMillion)
+	}
+	if s.PM25State != nil {
+		add(QuantityPM25, s.PM25State.MeasuredValue, UnitMicrogramsPerM3)
+	}
+	if s.FormaldehydeState != nil {
+		add(QuantityFormaldehyde, s.FormaldehydeState.MeasuredValue, UnitPartsPerMillion)
+	}
+	if s.MoistureState != nil {
+		add(QuantityMoisture, float64(s.MoistureState.Moisture)/100, UnitPercent)
+	}
+	if s.BatteryState != nil {
+		add(QuantityBattery, float64(s.BatteryState.Battery), UnitPercent)
+	} else if s.hasConfigField("battery") {
+		add(QuantityBattery, float64(s.Config.BatteryLevel), UnitPercent)
+	}
+
+	return ret
+}
+
+// hasConfigField checks whether the gateway included the field in the sensor config.
+func (s *Sensor) hasConfigField(field string) bool {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(s.ConfigRaw, &fields); err != nil {
+		return false
+	}
+	v, ok := fields[field]
+	return ok && string(v) != "null"
+}
@@ -0,0 +1,58 @@
+package deconz
+
+import "testing"
+
+func TestMeasurementsFahrenheit(t *testing.T) {
+	s := &Sensor{TemperatureState: &ZHATemperature{Temperature: 2000}}
+
+	got := s.Measurements()
+	if len(got) != 1 || got[0].Unit != UnitCelsius || !nearFloat(got[0].Value, 20) {
+		t.Errorf("Measurements() = %+v, want 20 degrees Celsius", got)
+	}
+
+	got = s.Measurements(UnitFahrenheit)
+	if len(got) != 1 || got[0].Unit != UnitFahrenheit || !nearFloat(got[0].Value, 68) {
+		t.Errorf("Measurements(UnitFahrenheit) = %+v, want 68 degrees Fahrenheit", got)
+	}
+}
+
+func TestMeasurementsPowerScale(t *testing.T) {
+	s := &Sensor{PowerState: &ZHAPower{Power: 120, Voltage: 2300, Current: 500}}
+
+	got := s.Measurements()
+	if len(got) != 3 || !nearFloat(got[0].Value, 120) || !nearFloat(got[1].Value, 2300) || !nearFloat(got[2].Value, 0.5) {
+		t.Errorf("Measurements() with the default scale = %+v, want 120W, 2300V and 0.5A", got)
+	}
+
+	RegisterPowerScale("test-power-model", PowerScale{Power: 1, Voltage: 0.1, Current: 0.01})
+	t.Cleanup(func() {
+		modelPowerScalesMu.Lock()
+		defer modelPowerScalesMu.Unlock()
+		delete(modelPowerScales, "test-power-model")
+	})
+	s.ModelID = "test-power-model"
+	got = s.Measurements()
+	if len(got) != 3 || !nearFloat(got[0].Value, 120) || !nearFloat(got[1].Value, 230) || !nearFloat(got[2].Value, 5) {
+		t.Errorf("Measurements() with a registered scale = %+v, want 120W, 230V and 5A", got)
+	}
+}
+
+func TestPowerScaleForModel(t *testing.T) {
+	if scale, ok := PowerScaleForModel("SPLZB-131"); !ok || scale.Voltage != 0.01 {
+		t.Errorf("PowerScaleForModel(SPLZB-131) = %+v, %t, want a voltage in hundredths", scale, ok)
+	}
+	if scale, ok := PowerScaleForModel("unknown-model"); ok || scale != DefaultPowerScale {
+		t.Errorf("PowerScaleForModel(unknown-model) = %+v, %t, want the default scale", scale, ok)
+	}
+
+	s := &Sensor{PowerState: &ZHAPower{Power: 40, Voltage: 23012, Current: 190}}
+	s.ModelID = "SmartPlug"
+	got := s.Measurements()
+	if len(got) != 3 || !nearFloat(got[1].Value, 230.12) || !nearFloat(got[2].Value, 1.9) {
+		t.Errorf("Measurements() of a SmartPlug = %+v, want 230.12V and 1.9A", got)
+	}
+}
+
+func nearFloat(got, want float64) bool {
+	return got-want < 1e-6 && want-got < 1e-6
+}
@@ -0,0 +1,19 @@
+package deconz
+
+// ResourceLink groups a set of related resources on the gateway together.
+type ResourceLink struct {
+	// ID contains the bridge-specified ID of this resource link.
+	ID          string
+	ClassID     int    `json:"classid"`
+	Description string `json:"description"`
+	ETag        string `json:"etag"`
+	// Links contains the addresses of the linked resources, i.e. /sensors/1
+	Links   []string `json:"links"`
+	Name    string   `json:"name"`
+	Owner   string   `json:"owner"`
+	Recycle bool     `json:"recycle"`
+	Type    string   `json:"type"`
+}
+
+// GetResourceLinksResponse contains the set of resource links.
+type GetResourceLinksResponse map[string]ResourceLink
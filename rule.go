@@ -1,6 +1,72 @@
 package deconz
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// CreateRule creates a new rule on the gateway. The new ID is returned on success.
+func (c *Client) CreateRule(ctx context.Context, req *CreateRuleRequest) (int, error) {
+	results, err := c.post(ctx, "rules", req)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(results) < 1 {
+		return 0, errors.New("new rule missing success entry")
+	}
+	if results[0].Address == "id" {
+		if strID, ok := results[0].Value.(string); ok {
+			return strconv.Atoi(strID)
+		}
+		return 0, errors.New("new rule id not string")
+	}
+
+	return 0, errors.New("new rule missing id entry")
+}
+
+// GetRules retrieves all the rules available on the gateway
+func (c *Client) GetRules(ctx context.Context) (GetRulesResponse, error) {
+	rulesResp := GetRulesResponse{}
+
+	err := c.get(ctx, "rules", &rulesResp)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, rule := range rulesResp {
+		rule.ID = id
+		rulesResp[id] = rule
+	}
+
+	return rulesResp, nil
+}
+
+// GetRule retrieves the specified rule
+func (c *Client) GetRule(ctx context.Context, id int) (*Rule, error) {
+	rule := &Rule{}
+
+	err := c.get(ctx, "rules/"+strconv.Itoa(id), rule)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.ID = strconv.Itoa(id)
+	return rule, nil
+}
+
+// SetRule specifies the new fields of a rule.
+// The results contain each field the gateway applied or rejected; an UpdateError is returned if any were rejected.
+func (c *Client) SetRule(ctx context.Context, id int, newRule *SetRulesRequest) (UpdateResults, error) {
+	return c.put(ctx, "rules/"+strconv.Itoa(id), newRule)
+}
+
+// DeleteRule removes the specified rule from the gateway
+func (c *Client) DeleteRule(ctx context.Context, id int) error {
+	return c.delete(ctx, "rules/"+strconv.Itoa(id))
+}
 
 // Rule contains the fields of a rule
 type Rule struct {
@@ -8,9 +74,9 @@ type Rule struct {
 	ID              string
 	Actions         []RuleAction    `json:"actions"`
 	Conditions      []RuleCondition `json:"conditions"`
-	CreatedAt       string          `json:"created"`
+	CreatedAt       Time            `json:"created"`
 	ETag            string          `json:"etag"`
-	LastTriggeredAt string          `json:"lasttriggered"`
+	LastTriggeredAt Time            `json:"lasttriggered"`
 	Name            string          `json:"name"`
 	Owner           string          `json:"owner"`
 	Periodic        int             `json:"periodic"`
@@ -36,6 +102,18 @@ type RuleCondition struct {
 	Value    string `json:"value,omitempty"`
 }
 
+// CreateRuleRequest contains the fields used to create a new rule.
+// At least one action and one condition must be supplied.
+type CreateRuleRequest struct {
+	Actions    []RuleAction    `json:"actions"`
+	Conditions []RuleCondition `json:"conditions"`
+	Name       string          `json:"name"`
+	// Periodic contains the interval, in milliseconds, the rule is evaluated at. 0 means only on change.
+	Periodic int `json:"periodic,omitempty"`
+	// Status can be one of "enabled", "disabled"
+	Status string `json:"status,omitempty"`
+}
+
 // GetRulesResponse contains the data returned by a call to list the rules.
 type GetRulesResponse map[string]Rule
 
@@ -44,6 +122,6 @@ type SetRulesRequest struct {
 	Actions    []RuleAction    `json:"actions,omitempty"`
 	Conditions []RuleCondition `json:"conditions,omitempty"`
 	Name       string          `json:"name,omitempty"`
-	Perodic    int             `json:"perodic,omitempty"`
+	Perodic    int             `json:"periodic,omitempty"`
 	Status     string          `json:"status,omitempty"`
 }
@@ -0,0 +1,82 @@
+package deconz_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rmrobinson/deconz-go"
+	"github.com/rmrobinson/deconz-go/deconztest"
+)
+
+func TestRuleLifecycle(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	flagID, err := client.CreateSensor(ctx, deconz.NewCLIPGenericFlag("Away", "away-flag", false))
+	if err != nil {
+		t.Fatalf("CreateSensor returned error: %v", err)
+	}
+
+	id, err := client.CreateRule(ctx, &deconz.CreateRuleRequest{
+		Name: "Away",
+		Conditions: []deconz.RuleCondition{
+			{Address: "/sensors/" + flagID + "/state/flag", Operator: "eq", Value: "true"},
+		},
+		Actions: []deconz.RuleAction{
+			{Address: "/groups/0/action", Method: "PUT", Body: json.RawMessage(`{"on":false}`)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateRule returned error: %v", err)
+	}
+
+	rules, err := client.GetRules(ctx)
+	if err != nil {
+		t.Fatalf("GetRules returned error: %v", err)
+	}
+	if len(rules) != 1 {
+		t.Fatalf("GetRules returned %d rules, want 1", len(rules))
+	}
+
+	rule, err := client.GetRule(ctx, id)
+	if err != nil {
+		t.Fatalf("GetRule returned error: %v", err)
+	}
+	if rule.Name != "Away" || rule.Status != "enabled" || len(rule.Conditions) != 1 || len(rule.Actions) != 1 {
+		t.Errorf("GetRule = %+v, want the enabled rule which was created", rule)
+	}
+
+	results, err := client.SetRule(ctx, id, &deconz.SetRulesRequest{Status: "disabled"})
+	if err != nil {
+		t.Fatalf("SetRule returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Value != "disabled" {
+		t.Errorf("SetRule results = %+v, want the status applied", results)
+	}
+	// Nothing is changed by an empty request, which must not be mistaken for a malformed response
+	results, err = client.SetRule(ctx, id, &deconz.SetRulesRequest{})
+	if err != nil {
+		t.Fatalf("SetRule with an empty request returned error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("SetRule with an empty request results = %+v, want none", results)
+	}
+
+	rule, err = client.GetRule(ctx, id)
+	if err != nil {
+		t.Fatalf("GetRule returned error: %v", err)
+	}
+	if rule.Name != "Away" || rule.Status != "disabled" {
+		t.Errorf("GetRule after SetRule = %+v, want the rule disabled with its name kept", rule)
+	}
+
+	if err := client.DeleteRule(ctx, id); err != nil {
+		t.Fatalf("DeleteRule returned error: %v", err)
+	}
+	if _, err := client.GetRule(ctx, id); !deconz.IsResourceNotAvailable(err) {
+		t.Errorf("GetRule after DeleteRule returned %v, want ErrResourceNotAvailable", err)
+	}
+}
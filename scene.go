@@ -8,16 +8,16 @@ import (
 
 // CreateScene creates a new scene for the specified group on the gateway. The new scene ID is returned on success.
 func (c *Client) CreateScene(ctx context.Context, groupID int, req *CreateSceneRequest) (int, error) {
-	resp, err := c.post(ctx, "groups/"+strconv.Itoa(groupID)+"/scenes", req)
+	results, err := c.post(ctx, "groups/"+strconv.Itoa(groupID)+"/scenes", req)
 	if err != nil {
 		return 0, err
 	}
 
-	if len(*resp) < 1 {
+	if len(results) < 1 {
 		return 0, errors.New("new scene missing success entry")
 	}
-	if id, ok := (*resp)[0].Success["id"]; ok {
-		if strID, ok := id.(string); ok {
+	if results[0].Address == "id" {
+		if strID, ok := results[0].Value.(string); ok {
 			return strconv.Atoi(strID)
 		}
 		return 0, errors.New("new scene id not string")
@@ -52,26 +52,30 @@ func (c *Client) GetScene(ctx context.Context, groupID int, sceneID int) (*Scene
 
 // SetSceneConfig specifies the new config of a scene in the specified group
 func (c *Client) SetSceneConfig(ctx context.Context, groupID, sceneID int, newConfig *SetSceneConfigRequest) error {
-	return c.put(ctx, "groups/"+strconv.Itoa(groupID)+"/scenes/"+strconv.Itoa(sceneID), newConfig)
+	_, err := c.put(ctx, "groups/"+strconv.Itoa(groupID)+"/scenes/"+strconv.Itoa(sceneID), newConfig)
+	return err
 }
 
 // StoreScene saves the current state of the lights in the group to the supplied scene ID
 func (c *Client) StoreScene(ctx context.Context, groupID, sceneID int) error {
 	req := &EmptyRequest{}
-	return c.put(ctx, "groups/"+strconv.Itoa(groupID)+"/scenes/"+strconv.Itoa(sceneID)+"/store", req)
+	_, err := c.put(ctx, "groups/"+strconv.Itoa(groupID)+"/scenes/"+strconv.Itoa(sceneID)+"/store", req)
+	return err
 }
 
 // RecallScene applies the saved light state from the specified scene ID to the lights in the specified group ID.
 // If a light is not currently on, the recall will have no effect.
 func (c *Client) RecallScene(ctx context.Context, groupID, sceneID int) error {
 	req := &EmptyRequest{}
-	return c.put(ctx, "groups/"+strconv.Itoa(groupID)+"/scenes/"+strconv.Itoa(sceneID)+"/recall", req)
+	_, err := c.put(ctx, "groups/"+strconv.Itoa(groupID)+"/scenes/"+strconv.Itoa(sceneID)+"/recall", req)
+	return err
 }
 
 // SetSceneLightState specifies the new state of a light in the specified scene.
 // If the light is not a member of the group the scene is linked to, this will fail.
 func (c *Client) SetSceneLightState(ctx context.Context, groupID, sceneID, lightID int, newState *SetSceneLightConfigRequest) error {
-	return c.put(ctx, "groups/"+strconv.Itoa(groupID)+"/scenes/"+strconv.Itoa(sceneID)+"/lights/"+strconv.Itoa(lightID)+"/state", newState)
+	_, err := c.put(ctx, "groups/"+strconv.Itoa(groupID)+"/scenes/"+strconv.Itoa(sceneID)+"/lights/"+strconv.Itoa(lightID)+"/state", newState)
+	return err
 }
 
 // DeleteScene removes the specified scene from the gateway
@@ -1,6 +1,72 @@
 package deconz
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// CreateSchedule creates a new schedule on the gateway. The new ID is returned on success.
+func (c *Client) CreateSchedule(ctx context.Context, req *CreateScheduleRequest) (int, error) {
+	results, err := c.post(ctx, "schedules", req)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(results) < 1 {
+		return 0, errors.New("new schedule missing success entry")
+	}
+	if results[0].Address == "id" {
+		if strID, ok := results[0].Value.(string); ok {
+			return strconv.Atoi(strID)
+		}
+		return 0, errors.New("new schedule id not string")
+	}
+
+	return 0, errors.New("new schedule missing id entry")
+}
+
+// GetSchedules retrieves all the schedules available on the gateway
+func (c *Client) GetSchedules(ctx context.Context) (GetSchedulesResponse, error) {
+	schedulesResp := GetSchedulesResponse{}
+
+	err := c.get(ctx, "schedules", &schedulesResp)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, schedule := range schedulesResp {
+		schedule.ID = id
+		schedulesResp[id] = schedule
+	}
+
+	return schedulesResp, nil
+}
+
+// GetSchedule retrieves the specified schedule
+func (c *Client) GetSchedule(ctx context.Context, id int) (*Schedule, error) {
+	schedule := &Schedule{}
+
+	err := c.get(ctx, "schedules/"+strconv.Itoa(id), schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.ID = strconv.Itoa(id)
+	return schedule, nil
+}
+
+// SetScheduleConfig specifies the new config of a schedule.
+// The results contain each field the gateway applied or rejected; an UpdateError is returned if any were rejected.
+func (c *Client) SetScheduleConfig(ctx context.Context, id int, newConfig *SetScheduleConfigRequest) (UpdateResults, error) {
+	return c.put(ctx, "schedules/"+strconv.Itoa(id), newConfig)
+}
+
+// DeleteSchedule removes the specified schedule from the gateway
+func (c *Client) DeleteSchedule(ctx context.Context, id int) error {
+	return c.delete(ctx, "schedules/"+strconv.Itoa(id))
+}
 
 // CreateScheduleRequest specifies the fields to create a new schedule.
 type CreateScheduleRequest struct {
@@ -46,13 +112,14 @@ type ScheduleCommand struct {
 }
 
 // SetScheduleConfigRequest contains the config fields of a schedule which can be edited.
+// Empty and nil fields are left unchanged on the gateway; the Bool helper can be used to fill in AutoDelete.
 type SetScheduleConfigRequest struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	Command     ScheduleCommand `json:"command"`
-	Status      string          `json:"status"`
-	AutoDelete  bool            `json:"autodelete"`
-	Time        string          `json:"time"`
+	Name        string           `json:"name,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Command     *ScheduleCommand `json:"command,omitempty"`
+	Status      string           `json:"status,omitempty"`
+	AutoDelete  *bool            `json:"autodelete,omitempty"`
+	Time        string           `json:"time,omitempty"`
 }
 
 // GetSchedulesResponse contains the set of schedules.
@@ -0,0 +1,63 @@
+package deconz_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rmrobinson/deconz-go"
+	"github.com/rmrobinson/deconz-go/deconztest"
+)
+
+func TestScheduleLifecycle(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	id, err := client.CreateSchedule(ctx, &deconz.CreateScheduleRequest{
+		Name:        "Wake up",
+		Description: "Lights on for weekdays",
+		Command:     deconz.ScheduleCommand{Address: "/api/" + deconztest.DefaultAPIKey + "/groups/0/action", Method: "PUT", Body: json.RawMessage(`{"on":true}`)},
+		Status:      "enabled",
+		Time:        "W124/T06:30:00",
+	})
+	if err != nil {
+		t.Fatalf("CreateSchedule returned error: %v", err)
+	}
+
+	schedules, err := client.GetSchedules(ctx)
+	if err != nil {
+		t.Fatalf("GetSchedules returned error: %v", err)
+	}
+	if len(schedules) != 1 {
+		t.Fatalf("GetSchedules returned %d schedules, want 1", len(schedules))
+	}
+
+	// A partial update leaves the other fields alone
+	results, err := client.SetScheduleConfig(ctx, id, &deconz.SetScheduleConfigRequest{Status: "disabled", AutoDelete: deconz.Bool(false)})
+	if err != nil {
+		t.Fatalf("SetScheduleConfig returned error: %v", err)
+	}
+	if len(results) != 2 || len(results.Failed()) != 0 {
+		t.Errorf("SetScheduleConfig results = %+v, want both fields applied", results)
+	}
+
+	schedule, err := client.GetSchedule(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSchedule returned error: %v", err)
+	}
+	if schedule.Status != "disabled" || schedule.AutoDelete {
+		t.Errorf("GetSchedule = %+v, want disabled without autodelete", schedule)
+	}
+	if schedule.Name != "Wake up" || schedule.Time != "W124/T06:30:00" || schedule.Command.Method != "PUT" {
+		t.Errorf("GetSchedule = %+v, want the fields which weren't updated kept", schedule)
+	}
+
+	if err := client.DeleteSchedule(ctx, id); err != nil {
+		t.Fatalf("DeleteSchedule returned error: %v", err)
+	}
+	if _, err := client.GetSchedule(ctx, id); !deconz.IsResourceNotAvailable(err) {
+		t.Errorf("GetSchedule after DeleteSchedule returned %v, want ErrResourceNotAvailable", err)
+	}
+}
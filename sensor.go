@@ -3,6 +3,7 @@ package deconz
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 )
 
 // GetSensors retrieves all the sensors available on the gatway
@@ -14,6 +15,11 @@ func (c *Client) GetSensors(ctx context.Context) (GetSensorsResponse, error) {
 		return nil, err
 	}
 
+	for id, sensor := range sensorsResp {
+		sensor.ID = id
+		sensorsResp[id] = sensor
+	}
+
 	return sensorsResp, nil
 }
 
@@ -26,24 +32,58 @@ func (c *Client) GetSensor(ctx context.Context, id string) (*Sensor, error) {
 		return nil, err
 	}
 
+	sensor.ID = id
 	return sensor, nil
 }
 
-// SetSensorState specifies the new state of a sensor
-func (c *Client) SetSensorState(ctx context.Context, id string, newState *SetSensorStateRequest) error {
+// SetSensorState specifies the new state of a sensor.
+// The results contain each field the gateway applied or rejected; an UpdateError is returned if any were rejected.
+func (c *Client) SetSensorState(ctx context.Context, id string, newState *SetSensorStateRequest) (UpdateResults, error) {
 	return c.put(ctx, "sensors/"+id+"/state", newState)
 }
 
-// SetSensor specifies the new options for a sensor
-func (c *Client) SetSensor(ctx context.Context, id string, newConfig *SetSensorRequest) error {
+// SetSensor specifies the new options for a sensor.
+// The results contain each field the gateway applied or rejected; an UpdateError is returned if any were rejected.
+func (c *Client) SetSensor(ctx context.Context, id string, newConfig *SetSensorRequest) (UpdateResults, error) {
 	return c.put(ctx, "sensors/"+id, newConfig)
 }
 
-// SetSensorConfig specifies the new config of a sensor
-func (c *Client) SetSensorConfig(ctx context.Context, id string, newConfig *SetSensorConfigRequest) error {
+// SetSensorConfig specifies the new config of a sensor.
+// The results contain each field the gateway applied or rejected; an UpdateError is returned if any were rejected.
+// A sensitivity is checked against the maximum the sensor reports, which requires retrieving the sensor first.
+func (c *Client) SetSensorConfig(ctx context.Context, id string, newConfig *SetSensorConfigRequest) (UpdateResults, error) {
+	if err := newConfig.Validate(); err != nil {
+		return nil, err
+	}
+	if newConfig.Sensitivity != nil {
+		max, err := c.sensorSensitivityMax(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if *newConfig.Sensitivity > max {
+			return nil, fmt.Errorf("%w: sensitivity %d not in 0..%d", ErrInvalidRequest, *newConfig.Sensitivity, max)
+		}
+	}
 	return c.put(ctx, "sensors/"+id+"/config", newConfig)
 }
 
+// sensorSensitivityMax retrieves the maximum sensitivity of the specified motion or vibration sensor.
+// ErrUnsupportedByDevice is returned if the sensor doesn't report one.
+func (c *Client) sensorSensitivityMax(ctx context.Context, id string) (int, error) {
+	sensor, err := c.GetSensor(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	if sensor.PresenceConfig != nil && sensor.PresenceConfig.SensitivityMax > 0 {
+		return sensor.PresenceConfig.SensitivityMax, nil
+	}
+	if sensor.VibrationConfig != nil && sensor.VibrationConfig.SensitivityMax > 0 {
+		return sensor.VibrationConfig.SensitivityMax, nil
+	}
+	return 0, fmt.Errorf("%w: sensor %s doesn't report a sensitivity", ErrUnsupportedByDevice, id)
+}
+
 // DeleteSensor removes the specified sensor from the gateway
 func (c *Client) DeleteSensor(ctx context.Context, id string) error {
 	return c.delete(ctx, "sensors/"+id)
@@ -66,14 +106,52 @@ type Sensor struct {
 	PressureState       *ZHAPressure
 	TemperatureState    *ZHATemperature
 	ThermostatState     *ZHAThermostat
-	VibrationState      *ZHAVibration
-	WaterState          *ZHAWater
-	ButtonState         *ZGPSwitch
+	// VibrationState is also set for CLIPVibration sensors, which only report the vibration field
+	VibrationState *ZHAVibration
+	WaterState     *ZHAWater
+	ButtonState    *ZGPSwitch
+
+	AirQualityState       *ZHAAirQuality
+	AncillaryControlState *ZHAAncillaryControl
+	BatteryState          *ZHABattery
+	CarbonDioxideState    *ZHACarbonDioxide
+	DaylightState         *Daylight
+	DaylightOffsetState   *CLIPDaylightOffset
+	DoorLockState         *ZHADoorLock
+	FormaldehydeState     *ZHAFormaldehyde
+	GenericFlagState      *CLIPGenericFlag
+	GenericStatusState    *CLIPGenericStatus
+	MoistureState         *ZHAMoisture
+	PM25State             *ZHAPM25
+	SpectralState         *ZHASpectral
+	TimeState             *ZHATime
+
+	// GenericState is decoded for every sensor, so the common fields are available even for types without a typed state.
+	GenericState *SensorState
+	// CustomState contains the state produced by a decoder registered for a type this package doesn't know
+	CustomState interface{}
+
+	// The following are filled in from the config of the sensor types which have type-specific config fields
+	DaylightConfig       *DaylightConfig
+	DaylightOffsetConfig *DaylightOffsetConfig
+	LightLevelConfig     *ZHALightLevelConfig
+	OffsetConfig         *SensorOffsetConfig
+	PresenceConfig       *ZHAPresenceConfig
+	ThermostatConfig     *ZHAThermostatConfig
+	VibrationConfig      *ZHAVibrationConfig
+
+	// DecodeErr is set, to a DecodeError, if the typed state or config couldn't be decoded.
+	// The other sensors are still returned in this case, and StateRaw and ConfigRaw contain what the gateway reported.
+	DecodeErr error
 }
 
 // SensorMetadata contains a bunch of fields about all sensors
 type SensorMetadata struct {
-	ID               int          `json:"ep"`
+	// ID contains the gateway-specified ID; could change.
+	// Exists only for accessing by path; dedup using UniqueID instead
+	ID string `json:"-"`
+	// Endpoint contains the Zigbee endpoint the sensor is reached on
+	Endpoint         int          `json:"ep"`
 	Config           SensorConfig `json:"config"`
 	ETag             string       `json:"etag"`
 	ManufacturerName string       `json:"manufacturername"`
@@ -85,9 +163,79 @@ type SensorMetadata struct {
 	UniqueID         string       `json:"uniqueid"`
 
 	StateRaw json.RawMessage `json:"state"`
+	// ConfigRaw contains the complete config object, including the fields which are specific to the sensor type.
+	// When the metadata is encoded, the fields of Config are applied on top of it.
+	ConfigRaw json.RawMessage `json:"-"`
+}
+
+// sensorMetadata has the fields, but not the methods, of SensorMetadata so it can be used for the default encoding.
+type sensorMetadata SensorMetadata
+
+// UnmarshalJSON decodes the metadata, keeping a copy of the complete config object.
+func (m *SensorMetadata) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, (*sensorMetadata)(m)); err != nil {
+		return err
+	}
+
+	raw := struct {
+		Config json.RawMessage `json:"config"`
+	}{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	m.ConfigRaw = raw.Config
+	return nil
 }
 
-// UnmarshalJSON is a custom unmarshaler for the State object
+// MarshalJSON encodes the metadata, including the type-specific fields of the config object.
+// The battery level is only written if the config already contained it or it is set, so encoding doesn't add a battery to mains powered sensors.
+func (m SensorMetadata) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(sensorMetadata(m))
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	config, err := m.Config.overlay(m.ConfigRaw)
+	if err != nil {
+		return nil, err
+	}
+	fields["config"] = config
+	return json.Marshal(fields)
+}
+
+// overlay sets the fields of the config on top of the raw config object.
+func (c SensorConfig) overlay(raw json.RawMessage) (json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
+	}
+
+	values := map[string]interface{}{
+		"on":        c.On,
+		"reachable": c.Reachable,
+	}
+	if _, ok := fields["battery"]; ok || c.BatteryLevel != 0 {
+		values["battery"] = c.BatteryLevel
+	}
+	for k, v := range values {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		fields[k] = b
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON is a custom unmarshaler for the State object.
+// The typed state is filled in by the decoder registered for the sensor type;
+// a typed state which can't be decoded is reported in DecodeErr rather than failing the sensor.
 func (s *Sensor) UnmarshalJSON(b []byte) error {
 	meta := &SensorMetadata{}
 	err := json.Unmarshal(b, meta)
@@ -96,59 +244,9 @@ func (s *Sensor) UnmarshalJSON(b []byte) error {
 	}
 
 	s.SensorMetadata = *meta
-
-	switch meta.Type {
-	case "ZHAAlarm":
-		s.AlarmState = &ZHAAlarm{}
-		err = json.Unmarshal(meta.StateRaw, s.AlarmState)
-	case "ZHACarbonMonoxide":
-		s.CarbonMonoxideState = &ZHACarbonMonoxide{}
-		err = json.Unmarshal(meta.StateRaw, s.CarbonMonoxideState)
-	case "ZHAConsumption":
-		s.ConsumptionState = &ZHAConsumption{}
-		err = json.Unmarshal(meta.StateRaw, s.ConsumptionState)
-	case "ZHAFire":
-		s.FireState = &ZHAFire{}
-		err = json.Unmarshal(meta.StateRaw, s.FireState)
-	case "ZHAHumidity":
-		s.HumidityState = &ZHAHumidity{}
-		err = json.Unmarshal(meta.StateRaw, s.HumidityState)
-	case "ZHALightLevel":
-		s.LightLevelState = &ZHALightLevel{}
-		err = json.Unmarshal(meta.StateRaw, s.LightLevelState)
-	case "ZHAOpenClose":
-		s.OpenCloseState = &ZHAOpenClose{}
-		err = json.Unmarshal(meta.StateRaw, s.OpenCloseState)
-	case "ZHAPower":
-		s.PowerState = &ZHAPower{}
-		err = json.Unmarshal(meta.StateRaw, s.PowerState)
-	case "ZHAPresence":
-		s.PresenceState = &ZHAPresence{}
-		err = json.Unmarshal(meta.StateRaw, s.PresenceState)
-	case "ZHASwitch":
-		s.SwitchState = &ZHASwitch{}
-		err = json.Unmarshal(meta.StateRaw, s.SwitchState)
-	case "ZHAPressure":
-		s.PressureState = &ZHAPressure{}
-		err = json.Unmarshal(meta.StateRaw, s.PressureState)
-	case "ZHATemperature":
-		s.TemperatureState = &ZHATemperature{}
-		err = json.Unmarshal(meta.StateRaw, s.TemperatureState)
-	case "ZHAThermostat":
-		s.ThermostatState = &ZHAThermostat{}
-		err = json.Unmarshal(meta.StateRaw, s.ThermostatState)
-	case "ZHAVibration":
-		s.VibrationState = &ZHAVibration{}
-		err = json.Unmarshal(meta.StateRaw, s.VibrationState)
-	case "ZHAWater":
-		s.WaterState = &ZHAWater{}
-		err = json.Unmarshal(meta.StateRaw, s.WaterState)
-	case "ZGPSwitch":
-		s.ButtonState = &ZGPSwitch{}
-		err = json.Unmarshal(meta.StateRaw, s.ButtonState)
-	}
-
-	return err
+	// The error is kept in DecodeErr
+	_ = s.Decode()
+	return nil
 }
 
 // SensorConfig contains the settable properties of a sensor
@@ -162,9 +260,9 @@ type SensorConfig struct {
 // This is a generic type which contains state for all possible Zigbee sensors.
 // Specific sensor types are subclassed and exposed with only their relevant fields.
 type SensorState struct {
-	LastUpdated string `json:"lastupdated"`
-	LowBattery  bool   `json:"lowbattery"`
-	Tampered    bool   `json:"tampered"`
+	LastUpdated Time `json:"lastupdated"`
+	LowBattery  bool `json:"lowbattery"`
+	Tampered    bool `json:"tampered"`
 
 	Alarm          bool   `json:"alarm"`
 	CarbonMonoxide bool   `json:"carbonmonoxide"`
@@ -190,101 +288,115 @@ type SensorState struct {
 	Temperature    int    `json:"temperature"`
 	Valve          int    `json:"valve"`
 	WindowOpen     string `json:"windowopen"`
+
+	Action           string  `json:"action"`
+	AirQuality       string  `json:"airquality"`
+	AirQualityPPB    int     `json:"airqualityppb"`
+	Battery          int     `json:"battery"`
+	Flag             bool    `json:"flag"`
+	LockState        string  `json:"lockstate"`
+	MeasuredValue    float64 `json:"measured_value"`
+	Moisture         int     `json:"moisture"`
+	Panel            string  `json:"panel"`
+	SecondsRemaining int     `json:"seconds_remaining"`
+	Status           int     `json:"status"`
+	Vibration        bool    `json:"vibration"`
+	Water            bool    `json:"water"`
 }
 
 // ZHAAlarm represents a Zigbee Home Automation Alarm
 type ZHAAlarm struct {
-	Alarm       bool   `json:"alarm"`
-	LastUpdated string `json:"lastupdated"`
-	LowBattery  bool   `json:"lowbattery"`
-	Tampered    bool   `json:"tampered"`
+	Alarm       bool `json:"alarm"`
+	LastUpdated Time `json:"lastupdated"`
+	LowBattery  bool `json:"lowbattery"`
+	Tampered    bool `json:"tampered"`
 }
 
 // ZHACarbonMonoxide represents a Zigbee Home Automation Carbon Monoxide detector
 type ZHACarbonMonoxide struct {
-	CarbonMonoxide bool   `json:"carbonmonoxide"`
-	LastUpdated    string `json:"lastupdated"`
-	LowBattery     bool   `json:"lowbattery"`
-	Tampered       bool   `json:"tampered"`
+	CarbonMonoxide bool `json:"carbonmonoxide"`
+	LastUpdated    Time `json:"lastupdated"`
+	LowBattery     bool `json:"lowbattery"`
+	Tampered       bool `json:"tampered"`
 }
 
 // ZHAConsumption represents a Zigbee Home Automation consumption monitor
 type ZHAConsumption struct {
-	Consumption int    `json:"consumption"`
-	LastUpdated string `json:"lastupdated"`
-	Power       int    `json:"power"`
+	Consumption int  `json:"consumption"`
+	LastUpdated Time `json:"lastupdated"`
+	Power       int  `json:"power"`
 }
 
 // ZHAFire represents a Zigbee Home Automation fire detector
 type ZHAFire struct {
-	Fire        bool   `json:"fire"`
-	LastUpdated string `json:"lastupdated"`
-	LowBattery  bool   `json:"lowbattery"`
-	Tampered    bool   `json:"tampered"`
+	Fire        bool `json:"fire"`
+	LastUpdated Time `json:"lastupdated"`
+	LowBattery  bool `json:"lowbattery"`
+	Tampered    bool `json:"tampered"`
 }
 
 // ZHAHumidity represents a Zigbee Home Automation humidity monitor
 type ZHAHumidity struct {
-	Humidity    int    `json:"humidity"`
-	LastUpdated string `json:"lastupdated"`
+	Humidity    int  `json:"humidity"`
+	LastUpdated Time `json:"lastupdated"`
 }
 
 // ZHALightLevel represents a Zigbee Home Automation light level sensor
 type ZHALightLevel struct {
-	Lux         int    `json:"lux"`
-	LastUpdated string `json:"lastupdated"`
-	LightLevel  int    `json:"lightlevel"`
-	Dark        bool   `json:"dark"`
-	Daylight    bool   `json:"daylight"`
+	Lux         int  `json:"lux"`
+	LastUpdated Time `json:"lastupdated"`
+	LightLevel  int  `json:"lightlevel"`
+	Dark        bool `json:"dark"`
+	Daylight    bool `json:"daylight"`
 }
 
 // ZHAOpenClose represents an open/close sensor
 type ZHAOpenClose struct {
-	Open        bool   `json:"open"`
-	LastUpdated string `json:"lastupdated"`
+	Open        bool `json:"open"`
+	LastUpdated Time `json:"lastupdated"`
 }
 
 // ZHAPower represents a Zigbee power monitor
 type ZHAPower struct {
-	Current     int    `json:"current"`
-	LastUpdated string `json:"lastupdated"`
-	Power       int    `json:"power"`
-	Voltage     int    `json:"voltage"`
+	Current     int  `json:"current"`
+	LastUpdated Time `json:"lastupdated"`
+	Power       int  `json:"power"`
+	Voltage     int  `json:"voltage"`
 }
 
 // ZHAPresence represents a Zigbee presence monitor
 type ZHAPresence struct {
-	Presence    bool   `json:"presence"`
-	LastUpdated string `json:"lastupdated"`
+	Presence    bool `json:"presence"`
+	LastUpdated Time `json:"lastupdated"`
 }
 
 // ZHASwitch represents a Zigbee switch
 type ZHASwitch struct {
-	ButtonEvent   int    `json:"buttonevent"`
-	LastUpdated   string `json:"lastupdated"`
-	Gesture       int    `json:"gesture"`
-	EventDuration int    `json:"eventduration"`
-	X             int    `json:"x"`
-	Y             int    `json:"y"`
-	Angle         int    `json:"angle"`
+	ButtonEvent   int  `json:"buttonevent"`
+	LastUpdated   Time `json:"lastupdated"`
+	Gesture       int  `json:"gesture"`
+	EventDuration int  `json:"eventduration"`
+	X             int  `json:"x"`
+	Y             int  `json:"y"`
+	Angle         int  `json:"angle"`
 }
 
 // ZHAPressure represents a Zigbee pressure monitor
 type ZHAPressure struct {
-	Pressure    int    `json:"pressure"`
-	LastUpdated string `json:"lastupdated"`
+	Pressure    int  `json:"pressure"`
+	LastUpdated Time `json:"lastupdated"`
 }
 
 // ZHATemperature represents a Zigbee temperature sensor
 type ZHATemperature struct {
-	Temperature int    `json:"temperature"`
-	LastUpdated string `json:"lastupdated"`
+	Temperature int  `json:"temperature"`
+	LastUpdated Time `json:"lastupdated"`
 }
 
 // ZHAThermostat represents a Zigbee thermostat
 type ZHAThermostat struct {
 	On          bool   `json:"on"`
-	LastUpdated string `json:"lastupdated"`
+	LastUpdated Time   `json:"lastupdated"`
 	Temperature int    `json:"temperature"`
 	Valve       int    `json:"valve"`
 	WindowOpen  string `json:"windowopen"`
@@ -292,27 +404,135 @@ type ZHAThermostat struct {
 
 // ZHAVibration represents a Zigbee vibration sensor
 type ZHAVibration struct {
-	Vibration         bool   `json:"vibration"`
-	LastUpdated       string `json:"lastupdated"`
-	OrientationX      int    `json:"orientation_x"`
-	OrientationY      int    `json:"orientation_y"`
-	OrientationZ      int    `json:"orientation_z"`
-	TiltAngle         int    `json:"tiltangle"`
-	VibrationStrength int    `json:"vibrationstrength"`
+	Vibration         bool `json:"vibration"`
+	LastUpdated       Time `json:"lastupdated"`
+	OrientationX      int  `json:"orientation_x"`
+	OrientationY      int  `json:"orientation_y"`
+	OrientationZ      int  `json:"orientation_z"`
+	TiltAngle         int  `json:"tiltangle"`
+	VibrationStrength int  `json:"vibrationstrength"`
 }
 
 // ZHAWater represents a Zigbee water sensor
 type ZHAWater struct {
-	Water       bool   `json:"water"`
-	LastUpdated string `json:"lastupdated"`
-	LowBattery  bool   `json:"lowbattery"`
-	Tampered    bool   `json:"tampered"`
+	Water       bool `json:"water"`
+	LastUpdated Time `json:"lastupdated"`
+	LowBattery  bool `json:"lowbattery"`
+	Tampered    bool `json:"tampered"`
 }
 
 // ZGPSwitch represents a Zigbee general button event
 type ZGPSwitch struct {
-	ButtonEvent int    `json:"buttonevent"`
-	LastUpdated string `json:"lastupdated"`
+	ButtonEvent int  `json:"buttonevent"`
+	LastUpdated Time `json:"lastupdated"`
+}
+
+// ZHAAirQuality represents a Zigbee air quality sensor
+type ZHAAirQuality struct {
+	// AirQuality is one of "excellent", "good", "moderate", "poor", "unhealthy" or "out of scale"
+	AirQuality    string `json:"airquality"`
+	AirQualityPPB int    `json:"airqualityppb"`
+	LastUpdated   Time   `json:"lastupdated"`
+}
+
+// ZHAAncillaryControl represents a Zigbee alarm keypad
+type ZHAAncillaryControl struct {
+	// Action contains the last command entered, such as "armed_away" or "disarmed"
+	Action string `json:"action"`
+	// Panel contains the state shown on the keypad, such as "exit_delay"
+	Panel            string `json:"panel"`
+	SecondsRemaining int    `json:"seconds_remaining"`
+	Tampered         bool   `json:"tampered"`
+	LastUpdated      Time   `json:"lastupdated"`
+}
+
+// ZHABattery represents a Zigbee battery level reporter
+type ZHABattery struct {
+	// Battery is a percentage
+	Battery     int  `json:"battery"`
+	LastUpdated Time `json:"lastupdated"`
+}
+
+// ZHACarbonDioxide represents a Zigbee carbon dioxide sensor
+type ZHACarbonDioxide struct {
+	// MeasuredValue is in parts per million
+	MeasuredValue float64 `json:"measured_value"`
+	LastUpdated   Time    `json:"lastupdated"`
+}
+
+// Daylight represents the virtual sensor the gateway uses to track the position of the sun
+type Daylight struct {
+	Dark     bool `json:"dark"`
+	Daylight bool `json:"daylight"`
+	// Status contains the phase of the day, from 100 (nadir) to 230 (night end)
+	Status      int  `json:"status"`
+	Sunrise     Time `json:"sunrise"`
+	Sunset      Time `json:"sunset"`
+	LastUpdated Time `json:"lastupdated"`
+}
+
+// CLIPDaylightOffset represents a virtual sensor which tracks a time relative to sunrise or sunset
+type CLIPDaylightOffset struct {
+	// LocalTime contains the next time the offset occurs. The gateway reports it as a wall clock time in its own timezone,
+	// which isn't included in the sensor; it is decoded as UTC, so use GatewayState.Timezone to interpret it.
+	LocalTime   Time `json:"localtime"`
+	LastUpdated Time `json:"lastupdated"`
+}
+
+// ZHADoorLock represents a Zigbee door lock
+type ZHADoorLock struct {
+	// LockState is one of "locked", "unlocked", "not fully locked" or "undefined"
+	LockState   string `json:"lockstate"`
+	LastUpdated Time   `json:"lastupdated"`
+}
+
+// ZHAFormaldehyde represents a Zigbee formaldehyde sensor
+type ZHAFormaldehyde struct {
+	// MeasuredValue is in parts per million
+	MeasuredValue float64 `json:"measured_value"`
+	LastUpdated   Time    `json:"lastupdated"`
+}
+
+// CLIPGenericFlag represents a flag which can be set through the REST API
+type CLIPGenericFlag struct {
+	Flag        bool `json:"flag"`
+	LastUpdated Time `json:"lastupdated"`
+}
+
+// CLIPGenericStatus represents a status which can be set through the REST API
+type CLIPGenericStatus struct {
+	Status      int  `json:"status"`
+	LastUpdated Time `json:"lastupdated"`
+}
+
+// ZHAMoisture represents a Zigbee soil moisture sensor
+type ZHAMoisture struct {
+	// Moisture is in hundredths of a percent
+	Moisture    int  `json:"moisture"`
+	LastUpdated Time `json:"lastupdated"`
+}
+
+// ZHAPM25 represents a Zigbee particulate matter sensor
+type ZHAPM25 struct {
+	// MeasuredValue is in micrograms per cubic metre
+	MeasuredValue float64 `json:"measured_value"`
+	LastUpdated   Time    `json:"lastupdated"`
+}
+
+// ZHASpectral represents a Zigbee colour sensor
+type ZHASpectral struct {
+	SpectralX   int  `json:"spectral_x"`
+	SpectralY   int  `json:"spectral_y"`
+	SpectralZ   int  `json:"spectral_z"`
+	LastUpdated Time `json:"lastupdated"`
+}
+
+// ZHATime represents the clock of a Zigbee device
+type ZHATime struct {
+	LastSet     Time `json:"lastset"`
+	LocalTime   Time `json:"localtime"`
+	UTC         Time `json:"utc"`
+	LastUpdated Time `json:"lastupdated"`
 }
 
 // GetSensorsResponse contains the set of sensors in the gateway
@@ -327,30 +547,3 @@ type SetSensorRequest struct {
 	// 3 represents colour temperature mode
 	Mode int `json:"mode,omitempty"`
 }
-
-// SetSensorConfigRequest contains the fields of a sensor which can be changed.
-type SetSensorConfigRequest SensorConfig
-
-// SetSensorStateRequest contains the relevant properties which can be set.
-type SetSensorStateRequest struct {
-	// ButtonEvent is settable for CLIPSwitch type
-	ButtonEvent int `json:"buttonevent,omitempty"`
-
-	// Open is settable for CLIPOpenClose
-	Open bool `json:"open,omitempty"`
-
-	// Presence is settable for CLIPPresence
-	Presence bool `json:"presence,omitempty"`
-
-	// Temperature is settable for CLIPTemperature
-	Temperature int `json:"temperature,omitempty"`
-
-	// Flag is settable for CLIPGenericFlag
-	Flag bool `json:"flag,omitempty"`
-
-	// Status is settable for CLIPGenericStatus
-	Status int `json:"status,omitempty"`
-
-	// Humidity is settable for CLIPHumidity
-	Humidity int `json:"humidity,omitempty"`
-}
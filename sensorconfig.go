@@ -0,0 +1,239 @@
+package deconz
+
+import "fmt"
+
+// ZHAPresenceConfig contains the config fields of a motion sensor.
+type ZHAPresenceConfig struct {
+	// Delay is the number of seconds without motion before presence is cleared; used by Philips sensors
+	Delay int `json:"delay"`
+	// Duration is the number of seconds presence is reported for after motion; used by other sensors
+	Duration       int  `json:"duration"`
+	Sensitivity    int  `json:"sensitivity"`
+	SensitivityMax int  `json:"sensitivitymax"`
+	LEDIndication  bool `json:"ledindication"`
+	// UserTest enables the test mode, where motion is reported more quickly
+	UserTest bool   `json:"usertest"`
+	Alert    string `json:"alert"`
+	// Pending contains the fields which will be written to the device when it next wakes up
+	Pending []string `json:"pending"`
+}
+
+// ZHALightLevelConfig contains the config fields of a light level sensor.
+type ZHALightLevelConfig struct {
+	// TholdDark is the light level below which it is considered dark
+	TholdDark int `json:"tholddark"`
+	// TholdOffset is added to TholdDark to determine when it is considered daylight
+	TholdOffset int      `json:"tholdoffset"`
+	Alert       string   `json:"alert"`
+	Pending     []string `json:"pending"`
+}
+
+// SensorOffsetConfig contains the config fields of temperature, humidity and pressure sensors.
+type SensorOffsetConfig struct {
+	// Offset is added to the measured value, in the units the sensor reports
+	Offset  int      `json:"offset"`
+	Alert   string   `json:"alert"`
+	Pending []string `json:"pending"`
+}
+
+// ZHAVibrationConfig contains the config fields of a vibration sensor.
+type ZHAVibrationConfig struct {
+	Sensitivity    int      `json:"sensitivity"`
+	SensitivityMax int      `json:"sensitivitymax"`
+	Pending        []string `json:"pending"`
+}
+
+// DaylightConfig contains the config fields of the daylight sensor.
+type DaylightConfig struct {
+	// Configured is true once the location has been set
+	Configured bool `json:"configured"`
+	// Lat and Long are formatted with their hemisphere, for example '51.5000N'
+	Lat  string `json:"lat"`
+	Long string `json:"long"`
+	// SunriseOffset and SunsetOffset are in minutes
+	SunriseOffset int `json:"sunriseoffset"`
+	SunsetOffset  int `json:"sunsetoffset"`
+}
+
+// DaylightOffsetConfig contains the config fields of a daylight offset sensor.
+type DaylightOffsetConfig struct {
+	// Offset is in minutes, and may be negative
+	Offset int `json:"offset"`
+	// Mode is either 'sunrise' or 'sunset'
+	Mode string `json:"mode"`
+}
+
+// SetSensorConfigRequest contains the fields of a sensor config which can be changed.
+// Each sensor type only accepts some of the fields. Fields which are nil are not sent, so only the specified properties change;
+// NewSensorConfig and the With methods can be used to build a request without handling the pointers directly.
+type SetSensorConfigRequest struct {
+	On    *bool   `json:"on,omitempty"`
+	Alert *string `json:"alert,omitempty"`
+
+	// CLIP sensors, which report these on behalf of the device they represent
+	Reachable *bool `json:"reachable,omitempty"`
+	Battery   *int  `json:"battery,omitempty"`
+
+	// Motion sensors
+	Delay         *int  `json:"delay,omitempty"`
+	Duration      *int  `json:"duration,omitempty"`
+	Sensitivity   *int  `json:"sensitivity,omitempty"`
+	LEDIndication *bool `json:"ledindication,omitempty"`
+	UserTest      *bool `json:"usertest,omitempty"`
+
+	// Light level sensors
+	TholdDark   *int `json:"tholddark,omitempty"`
+	TholdOffset *int `json:"tholdoffset,omitempty"`
+
+	// Temperature, humidity and pressure sensors, and daylight offset sensors
+	Offset *int `json:"offset,omitempty"`
+
+	// Daylight offset sensors
+	Mode *string `json:"mode,omitempty"`
+
+	// The daylight sensor
+	Lat           *string `json:"lat,omitempty"`
+	Long          *string `json:"long,omitempty"`
+	SunriseOffset *int    `json:"sunriseoffset,omitempty"`
+	SunsetOffset  *int    `json:"sunsetoffset,omitempty"`
+}
+
+// Validate checks the request for values the gateway will not accept.
+// It is called by SetSensorConfig before the request is sent.
+func (r *SetSensorConfigRequest) Validate() error {
+	if r == nil {
+		return fmt.Errorf("%w: no sensor config specified", ErrInvalidRequest)
+	}
+	if r.Battery != nil && (*r.Battery < 0 || *r.Battery > 100) {
+		return fmt.Errorf("%w: battery %d not in 0..100", ErrInvalidRequest, *r.Battery)
+	}
+	if r.Delay != nil && (*r.Delay < 0 || *r.Delay > 65535) {
+		return fmt.Errorf("%w: delay %d not in 0..65535", ErrInvalidRequest, *r.Delay)
+	}
+	if r.Duration != nil && (*r.Duration < 0 || *r.Duration > 65535) {
+		return fmt.Errorf("%w: duration %d not in 0..65535", ErrInvalidRequest, *r.Duration)
+	}
+	// The maximum sensitivity is reported by each sensor, so it is checked by SetSensorConfig
+	if r.Sensitivity != nil && *r.Sensitivity < 0 {
+		return fmt.Errorf("%w: sensitivity %d is negative", ErrInvalidRequest, *r.Sensitivity)
+	}
+	if r.TholdDark != nil && (*r.TholdDark < 0 || *r.TholdDark > 65534) {
+		return fmt.Errorf("%w: tholddark %d not in 0..65534", ErrInvalidRequest, *r.TholdDark)
+	}
+	if r.TholdOffset != nil && (*r.TholdOffset < 1 || *r.TholdOffset > 65534) {
+		return fmt.Errorf("%w: tholdoffset %d not in 1..65534", ErrInvalidRequest, *r.TholdOffset)
+	}
+	if r.Mode != nil && *r.Mode != "sunrise" && *r.Mode != "sunset" {
+		return fmt.Errorf("%w: mode %s is not sunrise or sunset", ErrInvalidRequest, *r.Mode)
+	}
+	if r.SunriseOffset != nil && (*r.SunriseOffset < -120 || *r.SunriseOffset > 120) {
+		return fmt.Errorf("%w: sunriseoffset %d not in -120..120", ErrInvalidRequest, *r.SunriseOffset)
+	}
+	if r.SunsetOffset != nil && (*r.SunsetOffset < -120 || *r.SunsetOffset > 120) {
+		return fmt.Errorf("%w: sunsetoffset %d not in -120..120", ErrInvalidRequest, *r.SunsetOffset)
+	}
+	return nil
+}
+
+// NewSensorConfig creates an empty sensor config request; nothing is changed until one of the With methods is called.
+func NewSensorConfig() *SetSensorConfigRequest {
+	return &SetSensorConfigRequest{}
+}
+
+// WithOn sets whether the sensor is enabled.
+func (r *SetSensorConfigRequest) WithOn(on bool) *SetSensorConfigRequest {
+	r.On = &on
+	return r
+}
+
+// WithAlert makes the sensor identify itself, using 'select' or 'lselect'.
+func (r *SetSensorConfigRequest) WithAlert(alert string) *SetSensorConfigRequest {
+	r.Alert = &alert
+	return r
+}
+
+// WithReachable sets whether a CLIP sensor reports its device as reachable.
+func (r *SetSensorConfigRequest) WithReachable(reachable bool) *SetSensorConfigRequest {
+	r.Reachable = &reachable
+	return r
+}
+
+// WithBattery sets the battery level a CLIP sensor reports, as a percentage.
+func (r *SetSensorConfigRequest) WithBattery(battery int) *SetSensorConfigRequest {
+	r.Battery = &battery
+	return r
+}
+
+// WithDelay sets the number of seconds without motion before a Philips motion sensor clears presence.
+func (r *SetSensorConfigRequest) WithDelay(seconds int) *SetSensorConfigRequest {
+	r.Delay = &seconds
+	return r
+}
+
+// WithDuration sets the number of seconds a motion sensor reports presence for after motion.
+func (r *SetSensorConfigRequest) WithDuration(seconds int) *SetSensorConfigRequest {
+	r.Duration = &seconds
+	return r
+}
+
+// WithSensitivity sets the sensitivity of a motion or vibration sensor, from 0 to its reported maximum.
+func (r *SetSensorConfigRequest) WithSensitivity(sensitivity int) *SetSensorConfigRequest {
+	r.Sensitivity = &sensitivity
+	return r
+}
+
+// WithLEDIndication sets whether a motion sensor flashes its LED when it detects motion.
+func (r *SetSensorConfigRequest) WithLEDIndication(enabled bool) *SetSensorConfigRequest {
+	r.LEDIndication = &enabled
+	return r
+}
+
+// WithUserTest sets whether a motion sensor is in test mode.
+func (r *SetSensorConfigRequest) WithUserTest(enabled bool) *SetSensorConfigRequest {
+	r.UserTest = &enabled
+	return r
+}
+
+// WithTholdDark sets the light level below which a light level sensor reports dark.
+func (r *SetSensorConfigRequest) WithTholdDark(level int) *SetSensorConfigRequest {
+	r.TholdDark = &level
+	return r
+}
+
+// WithTholdOffset sets the amount above the dark threshold at which a light level sensor reports daylight.
+func (r *SetSensorConfigRequest) WithTholdOffset(offset int) *SetSensorConfigRequest {
+	r.TholdOffset = &offset
+	return r
+}
+
+// WithOffset sets the calibration offset of a temperature, humidity or pressure sensor, in the units it reports.
+// For a daylight offset sensor it sets the offset from sunrise or sunset, in minutes.
+func (r *SetSensorConfigRequest) WithOffset(offset int) *SetSensorConfigRequest {
+	r.Offset = &offset
+	return r
+}
+
+// WithMode sets whether a daylight offset sensor is relative to 'sunrise' or 'sunset'.
+func (r *SetSensorConfigRequest) WithMode(mode string) *SetSensorConfigRequest {
+	r.Mode = &mode
+	return r
+}
+
+// WithLocation sets the location of the daylight sensor, formatted with the hemisphere, for example '51.5000N' and '0.1200W'.
+func (r *SetSensorConfigRequest) WithLocation(lat, long string) *SetSensorConfigRequest {
+	r.Lat = &lat
+	r.Long = &long
+	return r
+}
+
+// WithSunriseOffset sets the offset of sunrise for the daylight sensor, in minutes.
+func (r *SetSensorConfigRequest) WithSunriseOffset(minutes int) *SetSensorConfigRequest {
+	r.SunriseOffset = &minutes
+	return r
+}
+
+// WithSunsetOffset sets the offset of sunset for the daylight sensor, in minutes.
+func (r *SetSensorConfigRequest) WithSunsetOffset(minutes int) *SetSensorConfigRequest {
+	r.SunsetOffset = &minutes
+	return r
+}
@@ -0,0 +1,102 @@
+package deconz_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rmrobinson/deconz-go"
+	"github.com/rmrobinson/deconz-go/deconztest"
+)
+
+func TestSetSensorConfigPartial(t *testing.T) {
+	b, err := json.Marshal(deconz.NewSensorConfig().WithAlert("none").WithReachable(false).WithBattery(0))
+	if err != nil {
+		t.Fatalf("marshal returned error: %v", err)
+	}
+	if string(b) != `{"alert":"none","reachable":false,"battery":0}` {
+		t.Errorf("request encoded as %s, want only the fields which were set", b)
+	}
+
+	if err := deconz.NewSensorConfig().WithBattery(101).Validate(); !errors.Is(err, deconz.ErrInvalidRequest) {
+		t.Errorf("Validate() with a battery of 101 returned %v, want ErrInvalidRequest", err)
+	}
+
+	b, err = json.Marshal(deconz.NewSensorConfig().WithMode("sunset").WithOffset(-30))
+	if err != nil {
+		t.Fatalf("marshal returned error: %v", err)
+	}
+	if string(b) != `{"offset":-30,"mode":"sunset"}` {
+		t.Errorf("daylight offset request encoded as %s, want the offset and mode", b)
+	}
+	if err := deconz.NewSensorConfig().WithMode("noon").Validate(); !errors.Is(err, deconz.ErrInvalidRequest) {
+		t.Errorf("Validate() with a mode of noon returned %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestSetSensorConfigSensitivity(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	motion := deconz.Sensor{}
+	motion.Name = "Hall motion"
+	motion.Type = "ZHAPresence"
+	motion.UniqueID = "00:11:22:33:44:55:66:cc-02-0406"
+	motion.StateRaw = json.RawMessage(`{"presence":false,"lastupdated":"none"}`)
+	motion.ConfigRaw = json.RawMessage(`{"on":true,"reachable":true,"battery":100,"delay":0,"sensitivity":1,"sensitivitymax":2}`)
+	motionID := server.AddSensor(motion)
+
+	temperature := deconz.Sensor{}
+	temperature.Name = "Hall temperature"
+	temperature.Type = "ZHATemperature"
+	temperature.UniqueID = "00:11:22:33:44:55:66:cc-02-0402"
+	temperature.StateRaw = json.RawMessage(`{"temperature":2100,"lastupdated":"none"}`)
+	temperature.ConfigRaw = json.RawMessage(`{"on":true,"reachable":true,"battery":100,"offset":0}`)
+	temperatureID := server.AddSensor(temperature)
+
+	if _, err := client.SetSensorConfig(ctx, motionID, deconz.NewSensorConfig().WithSensitivity(3)); !errors.Is(err, deconz.ErrInvalidRequest) {
+		t.Errorf("SetSensorConfig with a sensitivity above the maximum returned %v, want ErrInvalidRequest", err)
+	}
+	if _, err := client.SetSensorConfig(ctx, motionID, deconz.NewSensorConfig().WithSensitivity(2)); err != nil {
+		t.Errorf("SetSensorConfig with the maximum sensitivity returned error: %v", err)
+	}
+	if _, err := client.SetSensorConfig(ctx, temperatureID, deconz.NewSensorConfig().WithSensitivity(1)); !errors.Is(err, deconz.ErrUnsupportedByDevice) {
+		t.Errorf("SetSensorConfig with a sensitivity for a temperature sensor returned %v, want ErrUnsupportedByDevice", err)
+	}
+
+	sensor, err := client.GetSensor(ctx, motionID)
+	if err != nil {
+		t.Fatalf("GetSensor returned error: %v", err)
+	}
+	if sensor.PresenceConfig == nil || sensor.PresenceConfig.Sensitivity != 2 {
+		t.Errorf("PresenceConfig = %+v, want a sensitivity of 2", sensor.PresenceConfig)
+	}
+}
+
+func TestSetCLIPSensorConfig(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	config := &deconz.CreateSensorConfig{Battery: deconz.Int(100)}
+	id, err := client.CreateSensor(ctx, deconz.NewCLIPOpenClose("Garage door", "garage-door-1", false).WithConfig(config))
+	if err != nil {
+		t.Fatalf("CreateSensor returned error: %v", err)
+	}
+
+	if _, err := client.SetSensorConfig(ctx, id, deconz.NewSensorConfig().WithReachable(false).WithBattery(20)); err != nil {
+		t.Fatalf("SetSensorConfig returned error: %v", err)
+	}
+
+	sensor, err := client.GetSensor(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSensor returned error: %v", err)
+	}
+	if sensor.Config.Reachable || sensor.Config.BatteryLevel != 20 {
+		t.Errorf("config = %+v, want unreachable with a battery of 20", sensor.Config)
+	}
+}
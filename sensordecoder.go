@@ -0,0 +1,300 @@
+package deconz
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// SensorDecoder fills in the typed state of a sensor from its raw state and config.
+// The metadata, including StateRaw and ConfigRaw, is set before it is called.
+type SensorDecoder func(sensor *Sensor) error
+
+var (
+	sensorDecodersMu sync.RWMutex
+	// sensorDecoders contains the decoder of each sensor type; the CLIP types share the state of their Zigbee equivalent.
+	sensorDecoders = map[string]SensorDecoder{
+		"ZHAAlarm":            decodeAlarm,
+		"CLIPAlarm":           decodeAlarm,
+		"ZHACarbonMonoxide":   decodeCarbonMonoxide,
+		"CLIPCarbonMonoxide":  decodeCarbonMonoxide,
+		"ZHAConsumption":      decodeConsumption,
+		"ZHAFire":             decodeFire,
+		"CLIPFire":            decodeFire,
+		"ZHAHumidity":         decodeHumidity,
+		"CLIPHumidity":        decodeHumidity,
+		"ZHALightLevel":       decodeLightLevel,
+		"CLIPLightLevel":      decodeLightLevel,
+		"ZHAOpenClose":        decodeOpenClose,
+		"CLIPOpenClose":       decodeOpenClose,
+		"ZHAPower":            decodePower,
+		"ZHAPresence":         decodePresence,
+		"CLIPPresence":        decodePresence,
+		"ZHASwitch":           decodeSwitch,
+		"CLIPSwitch":          decodeSwitch,
+		"ZHAPressure":         decodePressure,
+		"CLIPPressure":        decodePressure,
+		"ZHATemperature":      decodeTemperature,
+		"CLIPTemperature":     decodeTemperature,
+		"ZHAThermostat":       decodeThermostat,
+		"ZHAVibration":        decodeVibration,
+		"CLIPVibration":       decodeCLIPVibration,
+		"ZHAWater":            decodeWater,
+		"CLIPWater":           decodeWater,
+		"ZGPSwitch":           decodeButton,
+		"ZHAAirQuality":       decodeAirQuality,
+		"ZHAAncillaryControl": decodeAncillaryControl,
+		"ZHABattery":          decodeBattery,
+		"CLIPBattery":         decodeBattery,
+		"ZHACarbonDioxide":    decodeCarbonDioxide,
+		"Daylight":            decodeDaylight,
+		"CLIPDaylightOffset":  decodeDaylightOffset,
+		"ZHADoorLock":         decodeDoorLock,
+		"ZHAFormaldehyde":     decodeFormaldehyde,
+		"CLIPGenericFlag":     decodeGenericFlag,
+		"CLIPGenericStatus":   decodeGenericStatus,
+		"ZHAMoisture":         decodeMoisture,
+		"ZHAPM25":             decodePM25,
+		"ZHASpectral":         decodeSpectral,
+		"ZHATime":             decodeTime,
+	}
+)
+
+// RegisterSensorDecoder sets the decoder used for a sensor type, replacing the decoder of a known type.
+// Decoders for types this package doesn't know typically store their result in Sensor.CustomState.
+func RegisterSensorDecoder(sensorType string, decoder SensorDecoder) {
+	sensorDecodersMu.Lock()
+	defer sensorDecodersMu.Unlock()
+
+	sensorDecoders[sensorType] = decoder
+}
+
+func sensorDecoder(sensorType string) (SensorDecoder, bool) {
+	sensorDecodersMu.RLock()
+	defer sensorDecodersMu.RUnlock()
+
+	decoder, ok := sensorDecoders[sensorType]
+	return decoder, ok
+}
+
+// Decode fills in the typed state of the sensor from StateRaw and ConfigRaw, replacing any typed state already set.
+// It is called when the sensor is decoded; calling it again applies decoders registered since then.
+// If the typed state or config can't be decoded, it is left nil and the error is also stored in DecodeErr;
+// the raw state and config, and the generic state, are still available.
+func (s *Sensor) Decode() error {
+	*s = Sensor{SensorMetadata: s.SensorMetadata}
+
+	if decoder, ok := sensorDecoder(s.Type); ok {
+		if err := decoder(s); err != nil {
+			*s = Sensor{
+				SensorMetadata: s.SensorMetadata,
+				DecodeErr:      &DecodeError{Err: fmt.Errorf("%s state or config: %w", s.Type, err)},
+			}
+		}
+	}
+
+	// The generic state is best effort; types it doesn't describe may use the same keys with other value types
+	if len(s.StateRaw) > 0 {
+		generic := &SensorState{}
+		if json.Unmarshal(s.StateRaw, generic) == nil {
+			s.GenericState = generic
+		}
+	}
+
+	if s.DecodeErr != nil {
+		return s.DecodeErr
+	}
+	return nil
+}
+
+// decodeRaw decodes a raw state or config; a missing one leaves the target empty.
+// Typed configs are decoded through a pointer to their field, so they stay nil unless the gateway reported a config.
+func decodeRaw(raw json.RawMessage, target interface{}) error {
+	if len(raw) < 1 {
+		return nil
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func decodeAlarm(s *Sensor) error {
+	s.AlarmState = &ZHAAlarm{}
+	return decodeRaw(s.StateRaw, s.AlarmState)
+}
+
+func decodeCarbonMonoxide(s *Sensor) error {
+	s.CarbonMonoxideState = &ZHACarbonMonoxide{}
+	return decodeRaw(s.StateRaw, s.CarbonMonoxideState)
+}
+
+func decodeConsumption(s *Sensor) error {
+	s.ConsumptionState = &ZHAConsumption{}
+	return decodeRaw(s.StateRaw, s.ConsumptionState)
+}
+
+func decodeFire(s *Sensor) error {
+	s.FireState = &ZHAFire{}
+	return decodeRaw(s.StateRaw, s.FireState)
+}
+
+func decodeHumidity(s *Sensor) error {
+	s.HumidityState = &ZHAHumidity{}
+	if err := decodeRaw(s.StateRaw, s.HumidityState); err != nil {
+		return err
+	}
+	return decodeRaw(s.ConfigRaw, &s.OffsetConfig)
+}
+
+func decodeLightLevel(s *Sensor) error {
+	s.LightLevelState = &ZHALightLevel{}
+	if err := decodeRaw(s.StateRaw, s.LightLevelState); err != nil {
+		return err
+	}
+	return decodeRaw(s.ConfigRaw, &s.LightLevelConfig)
+}
+
+func decodeOpenClose(s *Sensor) error {
+	s.OpenCloseState = &ZHAOpenClose{}
+	return decodeRaw(s.StateRaw, s.OpenCloseState)
+}
+
+func decodePower(s *Sensor) error {
+	s.PowerState = &ZHAPower{}
+	return decodeRaw(s.StateRaw, s.PowerState)
+}
+
+func decodePresence(s *Sensor) error {
+	s.PresenceState = &ZHAPresence{}
+	if err := decodeRaw(s.StateRaw, s.PresenceState); err != nil {
+		return err
+	}
+	return decodeRaw(s.ConfigRaw, &s.PresenceConfig)
+}
+
+func decodeSwitch(s *Sensor) error {
+	s.SwitchState = &ZHASwitch{}
+	return decodeRaw(s.StateRaw, s.SwitchState)
+}
+
+func decodePressure(s *Sensor) error {
+	s.PressureState = &ZHAPressure{}
+	if err := decodeRaw(s.StateRaw, s.PressureState); err != nil {
+		return err
+	}
+	return decodeRaw(s.ConfigRaw, &s.OffsetConfig)
+}
+
+func decodeTemperature(s *Sensor) error {
+	s.TemperatureState = &ZHATemperature{}
+	if err := decodeRaw(s.StateRaw, s.TemperatureState); err != nil {
+		return err
+	}
+	return decodeRaw(s.ConfigRaw, &s.OffsetConfig)
+}
+
+func decodeThermostat(s *Sensor) error {
+	s.ThermostatState = &ZHAThermostat{}
+	if err := decodeRaw(s.StateRaw, s.ThermostatState); err != nil {
+		return err
+	}
+	return decodeRaw(s.ConfigRaw, &s.ThermostatConfig)
+}
+
+func decodeVibration(s *Sensor) error {
+	s.VibrationState = &ZHAVibration{}
+	if err := decodeRaw(s.StateRaw, s.VibrationState); err != nil {
+		return err
+	}
+	return decodeRaw(s.ConfigRaw, &s.VibrationConfig)
+}
+
+// decodeCLIPVibration uses the Zigbee state, but not its config, as CLIP sensors have no sensitivity.
+func decodeCLIPVibration(s *Sensor) error {
+	s.VibrationState = &ZHAVibration{}
+	return decodeRaw(s.StateRaw, s.VibrationState)
+}
+
+func decodeWater(s *Sensor) error {
+	s.WaterState = &ZHAWater{}
+	return decodeRaw(s.StateRaw, s.WaterState)
+}
+
+func decodeButton(s *Sensor) error {
+	s.ButtonState = &ZGPSwitch{}
+	return decodeRaw(s.StateRaw, s.ButtonState)
+}
+
+func decodeAirQuality(s *Sensor) error {
+	s.AirQualityState = &ZHAAirQuality{}
+	return decodeRaw(s.StateRaw, s.AirQualityState)
+}
+
+func decodeAncillaryControl(s *Sensor) error {
+	s.AncillaryControlState = &ZHAAncillaryControl{}
+	return decodeRaw(s.StateRaw, s.AncillaryControlState)
+}
+
+func decodeBattery(s *Sensor) error {
+	s.BatteryState = &ZHABattery{}
+	return decodeRaw(s.StateRaw, s.BatteryState)
+}
+
+func decodeCarbonDioxide(s *Sensor) error {
+	s.CarbonDioxideState = &ZHACarbonDioxide{}
+	return decodeRaw(s.StateRaw, s.CarbonDioxideState)
+}
+
+func decodeDaylight(s *Sensor) error {
+	s.DaylightState = &Daylight{}
+	if err := decodeRaw(s.StateRaw, s.DaylightState); err != nil {
+		return err
+	}
+	return decodeRaw(s.ConfigRaw, &s.DaylightConfig)
+}
+
+func decodeDaylightOffset(s *Sensor) error {
+	s.DaylightOffsetState = &CLIPDaylightOffset{}
+	if err := decodeRaw(s.StateRaw, s.DaylightOffsetState); err != nil {
+		return err
+	}
+	return decodeRaw(s.ConfigRaw, &s.DaylightOffsetConfig)
+}
+
+func decodeDoorLock(s *Sensor) error {
+	s.DoorLockState = &ZHADoorLock{}
+	return decodeRaw(s.StateRaw, s.DoorLockState)
+}
+
+func decodeFormaldehyde(s *Sensor) error {
+	s.FormaldehydeState = &ZHAFormaldehyde{}
+	return decodeRaw(s.StateRaw, s.FormaldehydeState)
+}
+
+func decodeGenericFlag(s *Sensor) error {
+	s.GenericFlagState = &CLIPGenericFlag{}
+	return decodeRaw(s.StateRaw, s.GenericFlagState)
+}
+
+func decodeGenericStatus(s *Sensor) error {
+	s.GenericStatusState = &CLIPGenericStatus{}
+	return decodeRaw(s.StateRaw, s.GenericStatusState)
+}
+
+func decodeMoisture(s *Sensor) error {
+	s.MoistureState = &ZHAMoisture{}
+	return decodeRaw(s.StateRaw, s.MoistureState)
+}
+
+func decodePM25(s *Sensor) error {
+	s.PM25State = &ZHAPM25{}
+	return decodeRaw(s.StateRaw, s.PM25State)
+}
+
+func decodeSpectral(s *Sensor) error {
+	s.SpectralState = &ZHASpectral{}
+	return decodeRaw(s.StateRaw, s.SpectralState)
+}
+
+func decodeTime(s *Sensor) error {
+	s.TimeState = &ZHATime{}
+	return decodeRaw(s.StateRaw, s.TimeState)
+}
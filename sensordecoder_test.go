@@ -0,0 +1,71 @@
+package deconz
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeCLIPVibration(t *testing.T) {
+	s := &Sensor{}
+	in := `{"type":"CLIPVibration","state":{"vibration":true,"lastupdated":"2021-03-04T05:06:07"},"config":{"on":true,"reachable":true}}`
+	if err := json.Unmarshal([]byte(in), s); err != nil {
+		t.Fatalf("unmarshal returned error: %v", err)
+	}
+
+	if s.VibrationState == nil || !s.VibrationState.Vibration {
+		t.Errorf("VibrationState = %+v, want vibration", s.VibrationState)
+	}
+	if s.VibrationConfig != nil {
+		t.Errorf("VibrationConfig = %+v, want nil for a CLIP sensor", s.VibrationConfig)
+	}
+}
+
+func TestDecodeCLIPDaylightOffset(t *testing.T) {
+	s := &Sensor{}
+	in := `{"type":"CLIPDaylightOffset","state":{"localtime":"2021-03-04T06:45:00","lastupdated":"none"},"config":{"on":true,"offset":-30,"mode":"sunrise"}}`
+	if err := json.Unmarshal([]byte(in), s); err != nil {
+		t.Fatalf("unmarshal returned error: %v", err)
+	}
+
+	if s.DaylightOffsetState == nil || s.DaylightOffsetState.LocalTime.Hour() != 6 || s.DaylightOffsetState.LocalTime.Minute() != 45 {
+		t.Errorf("DaylightOffsetState = %+v, want a local time of 06:45", s.DaylightOffsetState)
+	}
+	if s.DaylightOffsetConfig == nil || s.DaylightOffsetConfig.Offset != -30 || s.DaylightOffsetConfig.Mode != "sunrise" {
+		t.Errorf("DaylightOffsetConfig = %+v, want 30 minutes before sunrise", s.DaylightOffsetConfig)
+	}
+}
+
+func TestDecodeSensorsTolerant(t *testing.T) {
+	sensors := GetSensorsResponse{}
+	in := `{
+		"1":{"type":"ZHATemperature","state":{"temperature":"warm","lastupdated":"none"},"config":{"on":true,"offset":0}},
+		"2":{"type":"ZHAHumidity","state":{"humidity":4500},"config":{"on":true}},
+		"3":{"type":"ZHAPresence","state":{"presence":true},"config":{"on":true,"duration":"long"}}
+	}`
+	if err := json.Unmarshal([]byte(in), &sensors); err != nil {
+		t.Fatalf("unmarshal returned error: %v", err)
+	}
+	if len(sensors) != 3 {
+		t.Fatalf("decoded %d sensors, want 3", len(sensors))
+	}
+
+	for _, id := range []string{"1", "3"} {
+		s := sensors[id]
+		if !IsDecodeError(s.DecodeErr) {
+			t.Errorf("sensor %s DecodeErr = %v, want a DecodeError", id, s.DecodeErr)
+		}
+		if s.TemperatureState != nil || s.PresenceState != nil || s.PresenceConfig != nil {
+			t.Errorf("sensor %s has a typed state or config after failing to decode", id)
+		}
+		if len(s.StateRaw) < 1 || len(s.ConfigRaw) < 1 {
+			t.Errorf("sensor %s lost its raw state or config", id)
+		}
+	}
+	if s := sensors["3"]; s.GenericState == nil || !s.GenericState.Presence {
+		t.Errorf("sensor 3 GenericState = %+v, want the presence", s.GenericState)
+	}
+
+	if s := sensors["2"]; s.DecodeErr != nil || s.HumidityState == nil || s.HumidityState.Humidity != 4500 {
+		t.Errorf("sensor 2 = %+v, %v, want a humidity of 4500", s.HumidityState, s.DecodeErr)
+	}
+}
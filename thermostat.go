@@ -0,0 +1,169 @@
+package deconz
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// The thermostat modes accepted by the gateway; not every device supports all of them.
+const (
+	ThermostatModeOff       = "off"
+	ThermostatModeAuto      = "auto"
+	ThermostatModeHeat      = "heat"
+	ThermostatModeCool      = "cool"
+	ThermostatModeSleep     = "sleep"
+	ThermostatModeFanOnly   = "fan only"
+	ThermostatModeDry       = "dry"
+	ThermostatModeEmergency = "emergency heating"
+)
+
+// ZHAThermostatConfig contains the config fields of a Zigbee thermostat.
+// Temperatures are reported in hundredths of a degree Celsius; the Celsius methods convert them.
+type ZHAThermostatConfig struct {
+	HeatSetpoint       int    `json:"heatsetpoint"`
+	Mode               string `json:"mode"`
+	Offset             int    `json:"offset"`
+	Locked             bool   `json:"locked"`
+	DisplayFlipped     bool   `json:"displayflipped"`
+	ExternalSensorTemp int    `json:"externalsensortemp"`
+	// ScheduleOn is true if the device is following its weekly schedule
+	ScheduleOn bool `json:"schedule_on"`
+	// Schedule contains the weekly schedule
+	Schedule WeeklySchedule `json:"schedule"`
+}
+
+// HeatSetpointCelsius returns the target temperature in degrees Celsius.
+func (c ZHAThermostatConfig) HeatSetpointCelsius() float64 {
+	return fromCentiDegrees(c.HeatSetpoint)
+}
+
+// OffsetCelsius returns the calibration offset of the temperature sensor in degrees Celsius.
+func (c ZHAThermostatConfig) OffsetCelsius() float64 {
+	return fromCentiDegrees(c.Offset)
+}
+
+// ExternalSensorTempCelsius returns the temperature provided by an external sensor in degrees Celsius.
+func (c ZHAThermostatConfig) ExternalSensorTempCelsius() float64 {
+	return fromCentiDegrees(c.ExternalSensorTemp)
+}
+
+// TemperatureCelsius returns the measured temperature in degrees Celsius.
+func (t ZHAThermostat) TemperatureCelsius() float64 {
+	return fromCentiDegrees(t.Temperature)
+}
+
+// SetThermostatConfigRequest contains the config fields of a thermostat which can be changed.
+// Fields which are nil are not sent, so only the specified properties change;
+// NewThermostatConfig and the With methods can be used to build a request without handling the pointers directly.
+type SetThermostatConfigRequest struct {
+	// HeatSetpoint is in hundredths of a degree Celsius, from 500 to 3200
+	HeatSetpoint *int   `json:"heatsetpoint,omitempty"`
+	Mode         string `json:"mode,omitempty"`
+	// Offset is in hundredths of a degree Celsius, from -500 to 500
+	Offset         *int  `json:"offset,omitempty"`
+	Locked         *bool `json:"locked,omitempty"`
+	DisplayFlipped *bool `json:"displayflipped,omitempty"`
+	// ExternalSensorTemp is in hundredths of a degree Celsius
+	ExternalSensorTemp *int            `json:"externalsensortemp,omitempty"`
+	ScheduleOn         *bool           `json:"schedule_on,omitempty"`
+	Schedule           *WeeklySchedule `json:"schedule,omitempty"`
+}
+
+// Validate checks the request for values the gateway will not accept.
+// It is called by SetThermostatConfig before the request is sent, which also checks the schedule against the limits of the thermostat model.
+func (r *SetThermostatConfigRequest) Validate() error {
+	if r == nil {
+		return fmt.Errorf("%w: no thermostat config specified", ErrInvalidRequest)
+	}
+	if r.HeatSetpoint != nil && (*r.HeatSetpoint < 500 || *r.HeatSetpoint > 3200) {
+		return fmt.Errorf("%w: heatsetpoint %d not in 500..3200", ErrInvalidRequest, *r.HeatSetpoint)
+	}
+	if r.Offset != nil && (*r.Offset < -500 || *r.Offset > 500) {
+		return fmt.Errorf("%w: offset %d not in -500..500", ErrInvalidRequest, *r.Offset)
+	}
+	return nil
+}
+
+// NewThermostatConfig creates an empty thermostat config request; nothing is changed until one of the With methods is called.
+func NewThermostatConfig() *SetThermostatConfigRequest {
+	return &SetThermostatConfigRequest{}
+}
+
+// WithHeatSetpoint sets the target temperature, in degrees Celsius.
+func (r *SetThermostatConfigRequest) WithHeatSetpoint(celsius float64) *SetThermostatConfigRequest {
+	setpoint := toCentiDegrees(celsius)
+	r.HeatSetpoint = &setpoint
+	return r
+}
+
+// WithMode sets the operating mode, using one of the ThermostatMode constants.
+func (r *SetThermostatConfigRequest) WithMode(mode string) *SetThermostatConfigRequest {
+	r.Mode = mode
+	return r
+}
+
+// WithOffset sets the calibration offset of the temperature sensor, in degrees Celsius.
+func (r *SetThermostatConfigRequest) WithOffset(celsius float64) *SetThermostatConfigRequest {
+	offset := toCentiDegrees(celsius)
+	r.Offset = &offset
+	return r
+}
+
+// WithLocked sets whether the controls on the device are locked.
+func (r *SetThermostatConfigRequest) WithLocked(locked bool) *SetThermostatConfigRequest {
+	r.Locked = &locked
+	return r
+}
+
+// WithDisplayFlipped sets whether the display on the device is upside down.
+func (r *SetThermostatConfigRequest) WithDisplayFlipped(flipped bool) *SetThermostatConfigRequest {
+	r.DisplayFlipped = &flipped
+	return r
+}
+
+// WithExternalSensorTemp provides the temperature measured by an external sensor, in degrees Celsius.
+func (r *SetThermostatConfigRequest) WithExternalSensorTemp(celsius float64) *SetThermostatConfigRequest {
+	temp := toCentiDegrees(celsius)
+	r.ExternalSensorTemp = &temp
+	return r
+}
+
+// WithScheduleOn sets whether the device follows its weekly schedule.
+func (r *SetThermostatConfigRequest) WithScheduleOn(on bool) *SetThermostatConfigRequest {
+	r.ScheduleOn = &on
+	return r
+}
+
+// WithSchedule replaces the weekly schedule; an empty schedule clears it.
+func (r *SetThermostatConfigRequest) WithSchedule(schedule WeeklySchedule) *SetThermostatConfigRequest {
+	r.Schedule = &schedule
+	return r
+}
+
+// SetThermostatConfig specifies the new config of a thermostat.
+// The results contain each field the gateway applied or rejected; an UpdateError is returned if any were rejected.
+// A schedule is checked against the limits of the thermostat model, which requires retrieving the sensor first.
+func (c *Client) SetThermostatConfig(ctx context.Context, id string, newConfig *SetThermostatConfigRequest) (UpdateResults, error) {
+	if err := newConfig.Validate(); err != nil {
+		return nil, err
+	}
+	if newConfig.Schedule != nil {
+		limits, err := c.thermostatScheduleLimits(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := newConfig.Schedule.Validate(limits); err != nil {
+			return nil, err
+		}
+	}
+	return c.put(ctx, "sensors/"+id+"/config", newConfig)
+}
+
+func toCentiDegrees(celsius float64) int {
+	return int(math.Round(celsius * 100))
+}
+
+func fromCentiDegrees(centi int) float64 {
+	return float64(centi) / 100
+}
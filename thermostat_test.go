@@ -0,0 +1,51 @@
+package deconz_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rmrobinson/deconz-go"
+	"github.com/rmrobinson/deconz-go/deconztest"
+)
+
+func TestSetThermostatScheduleLimits(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	thermostat := deconz.Sensor{}
+	thermostat.Name = "Bedroom"
+	thermostat.Type = "ZHAThermostat"
+	thermostat.ModelID = "SPZB0001"
+	thermostat.UniqueID = "00:11:22:33:44:55:66:cc-01-0201"
+	thermostat.StateRaw = json.RawMessage(`{"temperature":2000,"valve":0,"on":false,"lastupdated":"none"}`)
+	thermostat.ConfigRaw = json.RawMessage(`{"on":true,"reachable":true,"heatsetpoint":2000,"schedule":{},"schedule_on":false}`)
+	thermostatID := server.AddSensor(thermostat)
+
+	switchSensor := deconz.Sensor{}
+	switchSensor.Name = "Switch"
+	switchSensor.Type = "ZHASwitch"
+	switchSensor.UniqueID = "00:11:22:33:44:55:66:dd-01-1000"
+	switchID := server.AddSensor(switchSensor)
+
+	schedule := deconz.WeeklySchedule{{Days: deconz.Weekdays, Transitions: []deconz.ThermostatTransition{deconz.NewThermostatTransition(6, 0, 21)}}}
+	if _, err := client.SetThermostatSchedule(ctx, thermostatID, schedule); err != nil {
+		t.Errorf("SetThermostatSchedule returned error: %v", err)
+	}
+
+	// The Eurotronic Spirit only accepts up to 30 degrees, although the cluster allows 32
+	tooWarm := deconz.WeeklySchedule{{Days: deconz.Weekdays, Transitions: []deconz.ThermostatTransition{deconz.NewThermostatTransition(6, 0, 31)}}}
+	if _, err := client.SetThermostatSchedule(ctx, thermostatID, tooWarm); !errors.Is(err, deconz.ErrInvalidRequest) {
+		t.Errorf("SetThermostatSchedule above the model limit returned %v, want ErrInvalidRequest", err)
+	}
+	if _, err := client.SetThermostatConfig(ctx, thermostatID, deconz.NewThermostatConfig().WithSchedule(tooWarm)); !errors.Is(err, deconz.ErrInvalidRequest) {
+		t.Errorf("SetThermostatConfig above the model limit returned %v, want ErrInvalidRequest", err)
+	}
+
+	if _, err := client.SetThermostatSchedule(ctx, switchID, schedule); !errors.Is(err, deconz.ErrWrongResourceType) {
+		t.Errorf("SetThermostatSchedule on a switch returned %v, want ErrWrongResourceType", err)
+	}
+}
@@ -0,0 +1,391 @@
+package deconz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ScheduleDays is a bitmask of the days of the week a schedule entry applies to.
+// It uses the gateway encoding, where Monday is the most significant bit.
+type ScheduleDays uint8
+
+// The days of the week, and common combinations of them.
+const (
+	Sunday ScheduleDays = 1 << iota
+	Saturday
+	Friday
+	Thursday
+	Wednesday
+	Tuesday
+	Monday
+
+	Weekdays = Monday | Tuesday | Wednesday | Thursday | Friday
+	Weekend  = Saturday | Sunday
+	Everyday = Weekdays | Weekend
+)
+
+// String formats the days as the gateway does, for example 'W124' for the weekdays.
+func (d ScheduleDays) String() string {
+	return "W" + strconv.Itoa(int(d))
+}
+
+// ParseScheduleDays parses the gateway representation of a set of days, such as 'W124'.
+func ParseScheduleDays(s string) (ScheduleDays, error) {
+	if !strings.HasPrefix(s, "W") {
+		return 0, fmt.Errorf("%w: days %q must start with W", ErrInvalidSchedule, s)
+	}
+	days, err := strconv.Atoi(s[1:])
+	if err != nil || days < 1 || days > int(Everyday) {
+		return 0, fmt.Errorf("%w: days %q not in W1..W127", ErrInvalidSchedule, s)
+	}
+	return ScheduleDays(days), nil
+}
+
+// ThermostatTransition contains the setpoint a thermostat changes to at a time of day.
+type ThermostatTransition struct {
+	Hour   int
+	Minute int
+	// HeatSetpoint is in hundredths of a degree Celsius
+	HeatSetpoint int
+}
+
+// NewThermostatTransition creates a transition to the given temperature, in degrees Celsius, at a time of day.
+func NewThermostatTransition(hour, minute int, celsius float64) ThermostatTransition {
+	return ThermostatTransition{
+		Hour:         hour,
+		Minute:       minute,
+		HeatSetpoint: toCentiDegrees(celsius),
+	}
+}
+
+// HeatSetpointCelsius returns the target temperature in degrees Celsius.
+func (t ThermostatTransition) HeatSetpointCelsius() float64 {
+	return fromCentiDegrees(t.HeatSetpoint)
+}
+
+// LocalTime formats the time of the transition as the gateway does, for example 'T06:00'.
+func (t ThermostatTransition) LocalTime() string {
+	return fmt.Sprintf("T%02d:%02d", t.Hour, t.Minute)
+}
+
+// String formats the transition as it appears in a schedule entry, for example 'T06:00|2100'.
+func (t ThermostatTransition) String() string {
+	return t.LocalTime() + "|" + strconv.Itoa(t.HeatSetpoint)
+}
+
+// thermostatTransition is the form of a transition used by the REST API.
+type thermostatTransition struct {
+	LocalTime    string `json:"localtime"`
+	HeatSetpoint int    `json:"heatsetpoint"`
+}
+
+// MarshalJSON encodes the transition as the REST API expects it.
+func (t ThermostatTransition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(thermostatTransition{
+		LocalTime:    t.LocalTime(),
+		HeatSetpoint: t.HeatSetpoint,
+	})
+}
+
+// UnmarshalJSON decodes the transition as the REST API reports it.
+func (t *ThermostatTransition) UnmarshalJSON(b []byte) error {
+	raw := thermostatTransition{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	hour, minute, err := parseLocalTime(raw.LocalTime)
+	if err != nil {
+		return &DecodeError{Err: err}
+	}
+	t.Hour = hour
+	t.Minute = minute
+	t.HeatSetpoint = raw.HeatSetpoint
+	return nil
+}
+
+// parseLocalTime parses a time of day formatted as Thh:mm. Seconds, as in Thh:mm:ss, are accepted and ignored.
+func parseLocalTime(s string) (int, int, error) {
+	invalid := fmt.Errorf("%w: time %q not formatted as Thh:mm", ErrInvalidSchedule, s)
+	if (len(s) != 6 && len(s) != 9) || s[0] != 'T' || s[3] != ':' || (len(s) == 9 && s[6] != ':') {
+		return 0, 0, invalid
+	}
+
+	hour, ok := parseDigits(s[1:3], 23)
+	if !ok {
+		return 0, 0, invalid
+	}
+	minute, ok := parseDigits(s[4:6], 59)
+	if !ok {
+		return 0, 0, invalid
+	}
+	if len(s) == 9 {
+		if _, ok := parseDigits(s[7:9], 59); !ok {
+			return 0, 0, invalid
+		}
+	}
+	return hour, minute, nil
+}
+
+// parseDigits parses a two digit number no larger than max; unlike strconv.Atoi, signs are not accepted.
+func parseDigits(s string, max int) (int, bool) {
+	if len(s) != 2 || s[0] < '0' || s[0] > '9' || s[1] < '0' || s[1] > '9' {
+		return 0, false
+	}
+	v := int(s[0]-'0')*10 + int(s[1]-'0')
+	return v, v <= max
+}
+
+// ThermostatScheduleEntry contains the transitions a thermostat follows on a set of days.
+type ThermostatScheduleEntry struct {
+	Days        ScheduleDays
+	Transitions []ThermostatTransition
+}
+
+// String formats the entry, for example 'W124/T06:00|2100;T22:00|1700'.
+func (e ThermostatScheduleEntry) String() string {
+	transitions := make([]string, len(e.Transitions))
+	for i, transition := range e.Transitions {
+		transitions[i] = transition.String()
+	}
+	return e.Days.String() + "/" + strings.Join(transitions, ";")
+}
+
+// ParseThermostatScheduleEntry parses an entry formatted like 'W124/T06:00|2100;T22:00|1700'.
+func ParseThermostatScheduleEntry(s string) (ThermostatScheduleEntry, error) {
+	entry := ThermostatScheduleEntry{}
+
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return entry, fmt.Errorf("%w: schedule entry %q has no transitions", ErrInvalidSchedule, s)
+	}
+
+	days, err := ParseScheduleDays(parts[0])
+	if err != nil {
+		return entry, err
+	}
+	entry.Days = days
+
+	for _, part := range strings.Split(parts[1], ";") {
+		fields := strings.SplitN(part, "|", 2)
+		if len(fields) != 2 {
+			return entry, fmt.Errorf("%w: transition %q not formatted as Thh:mm|setpoint", ErrInvalidSchedule, part)
+		}
+		hour, minute, err := parseLocalTime(fields[0])
+		if err != nil {
+			return entry, err
+		}
+		setpoint, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return entry, fmt.Errorf("%w: setpoint %q is not a number", ErrInvalidSchedule, fields[1])
+		}
+		entry.Transitions = append(entry.Transitions, ThermostatTransition{
+			Hour:         hour,
+			Minute:       minute,
+			HeatSetpoint: setpoint,
+		})
+	}
+
+	return entry, nil
+}
+
+// WeeklySchedule contains the heating program of a thermostat.
+// Each day of the week should be covered by at most one entry.
+type WeeklySchedule []ThermostatScheduleEntry
+
+// String formats the schedule with one entry per line, in the order the entries were added.
+func (ws WeeklySchedule) String() string {
+	entries := make([]string, len(ws))
+	for i, entry := range ws {
+		entries[i] = entry.String()
+	}
+	return strings.Join(entries, "\n")
+}
+
+// ParseWeeklySchedule parses a schedule containing entries separated by whitespace or newlines.
+func ParseWeeklySchedule(s string) (WeeklySchedule, error) {
+	ws := WeeklySchedule{}
+	for _, field := range strings.Fields(s) {
+		entry, err := ParseThermostatScheduleEntry(field)
+		if err != nil {
+			return nil, err
+		}
+		ws = append(ws, entry)
+	}
+	return ws, nil
+}
+
+// MarshalJSON encodes the schedule as the REST API expects it: an object with one key per entry.
+func (ws WeeklySchedule) MarshalJSON() ([]byte, error) {
+	entries := map[string][]ThermostatTransition{}
+	for _, entry := range ws {
+		entries[entry.Days.String()] = entry.Transitions
+	}
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON decodes the schedule as the REST API reports it.
+// The schedule formatted as a string, as produced by String, is also accepted.
+func (ws *WeeklySchedule) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		parsed, err := ParseWeeklySchedule(str)
+		if err != nil {
+			return &DecodeError{Err: err}
+		}
+		*ws = parsed
+		return nil
+	}
+
+	entries := map[string][]ThermostatTransition{}
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return err
+	}
+
+	parsed := WeeklySchedule{}
+	for key, transitions := range entries {
+		days, err := ParseScheduleDays(key)
+		if err != nil {
+			return &DecodeError{Err: err}
+		}
+		parsed = append(parsed, ThermostatScheduleEntry{
+			Days:        days,
+			Transitions: transitions,
+		})
+	}
+	// Entries starting earlier in the week come first
+	sort.Slice(parsed, func(i, j int) bool {
+		return parsed[i].Days > parsed[j].Days
+	})
+
+	*ws = parsed
+	return nil
+}
+
+// ScheduleLimits contains the constraints a thermostat places on its weekly schedule.
+type ScheduleLimits struct {
+	// MaxTransitions is the most transitions a single entry can contain
+	MaxTransitions int
+	// MinHeatSetpoint and MaxHeatSetpoint are in hundredths of a degree Celsius
+	MinHeatSetpoint int
+	MaxHeatSetpoint int
+}
+
+// DefaultScheduleLimits are the limits of the Zigbee thermostat cluster, used for models without specific limits.
+var DefaultScheduleLimits = ScheduleLimits{
+	MaxTransitions:  10,
+	MinHeatSetpoint: 500,
+	MaxHeatSetpoint: 3200,
+}
+
+var (
+	modelScheduleLimitsMu sync.RWMutex
+	// modelScheduleLimits contains the limits of the models with a narrower setpoint range than the cluster allows
+	modelScheduleLimits = map[string]ScheduleLimits{
+		// Danfoss Ally and the Popp version of it accept 5 to 35 degrees
+		"eTRV0100": {MaxTransitions: 10, MinHeatSetpoint: 500, MaxHeatSetpoint: 3500},
+		"eT093WRO": {MaxTransitions: 10, MinHeatSetpoint: 500, MaxHeatSetpoint: 3500},
+		// Eurotronic Spirit accepts 5 to 30 degrees
+		"SPZB0001": {MaxTransitions: 10, MinHeatSetpoint: 500, MaxHeatSetpoint: 3000},
+	}
+)
+
+// ScheduleLimitsForModel returns the schedule limits of the thermostat model.
+// The default limits are returned, with false, if the model has no limits registered.
+func ScheduleLimitsForModel(modelID string) (ScheduleLimits, bool) {
+	modelScheduleLimitsMu.RLock()
+	defer modelScheduleLimitsMu.RUnlock()
+
+	limits, ok := modelScheduleLimits[modelID]
+	if !ok {
+		return DefaultScheduleLimits, false
+	}
+	return limits, true
+}
+
+// RegisterScheduleLimits sets the schedule limits of a thermostat model.
+func RegisterScheduleLimits(modelID string, limits ScheduleLimits) {
+	modelScheduleLimitsMu.Lock()
+	defer modelScheduleLimitsMu.Unlock()
+
+	modelScheduleLimits[modelID] = limits
+}
+
+// Validate checks that the schedule is well formed and within the supplied limits.
+func (ws WeeklySchedule) Validate(limits ScheduleLimits) error {
+	var covered ScheduleDays
+	for _, entry := range ws {
+		if entry.Days < 1 || entry.Days > Everyday {
+			return fmt.Errorf("%w: days %s not in W1..W127", ErrInvalidRequest, entry.Days)
+		}
+		if covered&entry.Days != 0 {
+			return fmt.Errorf("%w: days %s overlap with another entry", ErrInvalidRequest, entry.Days)
+		}
+		covered |= entry.Days
+
+		if len(entry.Transitions) < 1 {
+			return fmt.Errorf("%w: entry %s has no transitions", ErrInvalidRequest, entry.Days)
+		}
+		if limits.MaxTransitions > 0 && len(entry.Transitions) > limits.MaxTransitions {
+			return fmt.Errorf("%w: entry %s has %d transitions, more than %d", ErrInvalidRequest, entry.Days, len(entry.Transitions), limits.MaxTransitions)
+		}
+
+		last := -1
+		for _, transition := range entry.Transitions {
+			if transition.Hour < 0 || transition.Hour > 23 || transition.Minute < 0 || transition.Minute > 59 {
+				return fmt.Errorf("%w: time %s not in T00:00..T23:59", ErrInvalidRequest, transition.LocalTime())
+			}
+			minutes := transition.Hour*60 + transition.Minute
+			if minutes <= last {
+				return fmt.Errorf("%w: time %s is not after the previous transition", ErrInvalidRequest, transition.LocalTime())
+			}
+			last = minutes
+			if transition.HeatSetpoint < limits.MinHeatSetpoint || transition.HeatSetpoint > limits.MaxHeatSetpoint {
+				return fmt.Errorf("%w: heatsetpoint %d not in %d..%d", ErrInvalidRequest, transition.HeatSetpoint, limits.MinHeatSetpoint, limits.MaxHeatSetpoint)
+			}
+		}
+	}
+	return nil
+}
+
+// GetThermostatSchedule retrieves the weekly schedule of the specified thermostat.
+func (c *Client) GetThermostatSchedule(ctx context.Context, id string) (WeeklySchedule, error) {
+	sensor, err := c.GetSensor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sensor.DecodeErr != nil {
+		return nil, sensor.DecodeErr
+	}
+	if sensor.ThermostatConfig == nil {
+		return nil, fmt.Errorf("%w: sensor %s is a %s, not a thermostat", ErrWrongResourceType, id, sensor.Type)
+	}
+	return sensor.ThermostatConfig.Schedule, nil
+}
+
+// SetThermostatSchedule replaces the weekly schedule of the specified thermostat; an empty schedule clears it.
+// The schedule is checked against the limits registered for the model of the thermostat before it is sent.
+func (c *Client) SetThermostatSchedule(ctx context.Context, id string, schedule WeeklySchedule) (UpdateResults, error) {
+	return c.SetThermostatConfig(ctx, id, &SetThermostatConfigRequest{Schedule: &schedule})
+}
+
+// thermostatScheduleLimits retrieves the schedule limits of the specified thermostat.
+// ErrWrongResourceType is returned if the sensor isn't a thermostat.
+func (c *Client) thermostatScheduleLimits(ctx context.Context, id string) (ScheduleLimits, error) {
+	sensor, err := c.GetSensor(ctx, id)
+	if err != nil {
+		return ScheduleLimits{}, err
+	}
+	// The type is checked rather than the decoded config, so a schedule the gateway reported in an unknown form can still be replaced
+	if sensor.Type != "ZHAThermostat" {
+		return ScheduleLimits{}, fmt.Errorf("%w: sensor %s is a %s, not a thermostat", ErrWrongResourceType, id, sensor.Type)
+	}
+
+	limits, _ := ScheduleLimitsForModel(sensor.ModelID)
+	return limits, nil
+}
@@ -0,0 +1,193 @@
+package deconz
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseThermostatScheduleEntry(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ThermostatScheduleEntry
+	}{
+		{
+			"W124/T06:00|2100;T22:00|1700",
+			ThermostatScheduleEntry{Days: Weekdays, Transitions: []ThermostatTransition{{6, 0, 2100}, {22, 0, 1700}}},
+		},
+		{
+			"W3/T08:30|2000",
+			ThermostatScheduleEntry{Days: Weekend, Transitions: []ThermostatTransition{{8, 30, 2000}}},
+		},
+		{
+			"W127/T00:00:00|1500;T23:59:30|1600",
+			ThermostatScheduleEntry{Days: Everyday, Transitions: []ThermostatTransition{{0, 0, 1500}, {23, 59, 1600}}},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseThermostatScheduleEntry(tt.in)
+		if err != nil {
+			t.Errorf("ParseThermostatScheduleEntry(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseThermostatScheduleEntry(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseThermostatScheduleEntryInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"W124",
+		"124/T06:00|2100",
+		"W0/T06:00|2100",
+		"W128/T06:00|2100",
+		"W124/T06:00",
+		"W124/06:00|2100",
+		"W124/T-1:00|2100",
+		"W124/T24:00|2100",
+		"W124/T06:60|2100",
+		"W124/T6:00|2100",
+		"W124/T06:00:60|2100",
+		"W124/T06:00.00|2100",
+		"W124/T06:00|warm",
+	} {
+		if got, err := ParseThermostatScheduleEntry(in); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("ParseThermostatScheduleEntry(%q) = %+v, %v, want ErrInvalidSchedule", in, got, err)
+		}
+	}
+}
+
+func TestWeeklyScheduleString(t *testing.T) {
+	tests := []struct {
+		in   WeeklySchedule
+		want string
+	}{
+		{WeeklySchedule{}, ""},
+		{
+			WeeklySchedule{{Days: Weekdays, Transitions: []ThermostatTransition{NewThermostatTransition(6, 0, 21), NewThermostatTransition(22, 5, 17.5)}}},
+			"W124/T06:00|2100;T22:05|1750",
+		},
+		{
+			WeeklySchedule{
+				{Days: Weekdays, Transitions: []ThermostatTransition{{7, 0, 2000}}},
+				{Days: Weekend, Transitions: []ThermostatTransition{{9, 0, 2100}}},
+			},
+			"W124/T07:00|2000\nW3/T09:00|2100",
+		},
+	}
+
+	for _, tt := range tests {
+		got := tt.in.String()
+		if got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+
+		parsed, err := ParseWeeklySchedule(got)
+		if err != nil {
+			t.Errorf("ParseWeeklySchedule(%q) returned error: %v", got, err)
+			continue
+		}
+		if !reflect.DeepEqual(parsed, tt.in) {
+			t.Errorf("ParseWeeklySchedule(%q) = %+v, want %+v", got, parsed, tt.in)
+		}
+	}
+}
+
+func TestWeeklyScheduleJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want WeeklySchedule
+		// out is the encoding of the decoded schedule; it is the input if empty
+		out string
+	}{
+		{
+			name: "empty",
+			in:   `{}`,
+			want: WeeklySchedule{},
+		},
+		{
+			name: "entries sorted by first day",
+			in:   `{"W3":[{"heatsetpoint":2100,"localtime":"T09:00"}],"W124":[{"heatsetpoint":2000,"localtime":"T07:00"},{"heatsetpoint":1700,"localtime":"T22:00"}]}`,
+			want: WeeklySchedule{
+				{Days: Weekdays, Transitions: []ThermostatTransition{{7, 0, 2000}, {22, 0, 1700}}},
+				{Days: Weekend, Transitions: []ThermostatTransition{{9, 0, 2100}}},
+			},
+		},
+		{
+			name: "seconds",
+			in:   `{"W127":[{"heatsetpoint":1800,"localtime":"T06:00:00"}]}`,
+			want: WeeklySchedule{{Days: Everyday, Transitions: []ThermostatTransition{{6, 0, 1800}}}},
+			out:  `{"W127":[{"localtime":"T06:00","heatsetpoint":1800}]}`,
+		},
+		{
+			name: "string",
+			in:   `"W124/T06:00|2100"`,
+			want: WeeklySchedule{{Days: Weekdays, Transitions: []ThermostatTransition{{6, 0, 2100}}}},
+			out:  `{"W124":[{"localtime":"T06:00","heatsetpoint":2100}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		got := WeeklySchedule{}
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Errorf("%s: unmarshal returned error: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: unmarshal = %+v, want %+v", tt.name, got, tt.want)
+		}
+
+		b, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("%s: marshal returned error: %v", tt.name, err)
+			continue
+		}
+		want := tt.out
+		if len(want) < 1 {
+			want = tt.in
+		}
+		var gotJSON, wantJSON interface{}
+		json.Unmarshal(b, &gotJSON)
+		json.Unmarshal([]byte(want), &wantJSON)
+		if !reflect.DeepEqual(gotJSON, wantJSON) {
+			t.Errorf("%s: marshal = %s, want %s", tt.name, b, want)
+		}
+	}
+}
+
+func TestWeeklyScheduleJSONInvalid(t *testing.T) {
+	for _, in := range []string{
+		`{"W124":[{"heatsetpoint":2100,"localtime":"06:00"}]}`,
+		`{"X1":[{"heatsetpoint":2100,"localtime":"T06:00"}]}`,
+		`"W124/T25:00|2100"`,
+	} {
+		ws := WeeklySchedule{}
+		err := json.Unmarshal([]byte(in), &ws)
+		if !IsDecodeError(err) || !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("unmarshal %s returned %v, want a DecodeError wrapping ErrInvalidSchedule", in, err)
+		}
+	}
+}
+
+func TestClearThermostatSchedule(t *testing.T) {
+	b, err := json.Marshal(NewThermostatConfig().WithSchedule(nil))
+	if err != nil {
+		t.Fatalf("marshal returned error: %v", err)
+	}
+	if string(b) != `{"schedule":{}}` {
+		t.Errorf("clearing the schedule encoded as %s, want an empty schedule", b)
+	}
+
+	b, err = json.Marshal(NewThermostatConfig().WithScheduleOn(false))
+	if err != nil {
+		t.Fatalf("marshal returned error: %v", err)
+	}
+	if string(b) != `{"schedule_on":false}` {
+		t.Errorf("a request without a schedule encoded as %s, want the schedule left out", b)
+	}
+}
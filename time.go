@@ -0,0 +1,93 @@
+package deconz
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Time contains a timestamp reported by the gateway.
+// The zero value is used when the gateway reports 'none' or leaves the timestamp out; check it using IsZero.
+// Timestamps without a timezone are in UTC and timestamps with an offset are converted to UTC.
+// GatewayState.LocalTime is the exception; it is in the gateway timezone if that is known to this system.
+type Time struct {
+	time.Time
+}
+
+// zonedTimeLayouts contains the formats with a timezone, from the most to the least precise.
+// 'Z07:00' accepts both a trailing 'Z' and an offset such as '+01:00'.
+var zonedTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04Z0700",
+	"2006-01-02 15:04:05Z07:00",
+}
+
+// timeLayouts contains the formats used by the gateway, from the most to the least precise.
+var timeLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+}
+
+// ParseTime parses a timestamp in any of the formats used by the gateway.
+// Timestamps without a timezone are parsed as UTC; a trailing 'Z' or an offset such as '+01:00' is accepted and the result is converted to UTC.
+func ParseTime(s string) (Time, error) {
+	if len(s) < 1 || s == "none" {
+		return Time{}, nil
+	}
+
+	for _, layout := range zonedTimeLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return Time{t.UTC()}, nil
+		}
+	}
+
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, s, time.UTC)
+		if err == nil {
+			return Time{t}, nil
+		}
+	}
+
+	return Time{}, fmt.Errorf("time %q is not in a format used by the gateway", s)
+}
+
+// UnmarshalJSON decodes a timestamp in any of the formats used by the gateway.
+// Timestamps in an unknown format are decoded as the zero value, so one bad field doesn't fail the whole response.
+func (t *Time) UnmarshalJSON(b []byte) error {
+	var s *string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		*t = Time{}
+		return nil
+	}
+
+	*t, _ = ParseTime(*s)
+	return nil
+}
+
+// MarshalJSON encodes the timestamp as the gateway does, using 'none' for the zero value.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return json.Marshal("none")
+	}
+	if t.Nanosecond() != 0 {
+		return json.Marshal(t.Format("2006-01-02T15:04:05.000"))
+	}
+	return json.Marshal(t.Format("2006-01-02T15:04:05"))
+}
+
+// inLocation returns the same wall clock time in the supplied location.
+// It is used for timestamps which the gateway reports in its own timezone, without saying so.
+func (t Time) inLocation(loc *time.Location) Time {
+	if t.IsZero() {
+		return t
+	}
+	return Time{time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)}
+}
@@ -0,0 +1,109 @@
+package deconz
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "", want: time.Time{}},
+		{in: "none", want: time.Time{}},
+		{in: "2021-03-04T05:06:07", want: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{in: "2021-03-04T05:06:07Z", want: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{in: "2021-03-04T05:06:07.123", want: time.Date(2021, 3, 4, 5, 6, 7, 123000000, time.UTC)},
+		{in: "2021-03-04T05:06:07.123Z", want: time.Date(2021, 3, 4, 5, 6, 7, 123000000, time.UTC)},
+		{in: "2021-03-04T05:06", want: time.Date(2021, 3, 4, 5, 6, 0, 0, time.UTC)},
+		{in: "2021-03-04 05:06:07", want: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{in: "2021-03-04T05:06:07+01:00", want: time.Date(2021, 3, 4, 4, 6, 7, 0, time.UTC)},
+		{in: "2021-03-04T05:06:07.5-02:30", want: time.Date(2021, 3, 4, 7, 36, 7, 500000000, time.UTC)},
+		{in: "2021-03-04T05:06:07+0100", want: time.Date(2021, 3, 4, 4, 6, 7, 0, time.UTC)},
+		{in: "yesterday", wantErr: true},
+		{in: "2021-03-04", wantErr: true},
+		{in: "2021-03-04T05:06:07 CET", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseTime(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTime(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.in, got.Time, tt.want)
+		}
+	}
+}
+
+func TestTimeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: `"2021-03-04T05:06:07"`, want: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{in: `"2021-03-04T05:06:07+01:00"`, want: time.Date(2021, 3, 4, 4, 6, 7, 0, time.UTC)},
+		{in: `"none"`, want: time.Time{}},
+		{in: `null`, want: time.Time{}},
+		{in: `"not a time"`, want: time.Time{}},
+		{in: `12`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got := Time{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)}
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("unmarshal %s = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unmarshal %s returned error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("unmarshal %s = %v, want %v", tt.in, got.Time, tt.want)
+		}
+	}
+}
+
+func TestTimeMarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   Time
+		want string
+	}{
+		{in: Time{}, want: `"none"`},
+		{in: Time{time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)}, want: `"2021-03-04T05:06:07"`},
+		{in: Time{time.Date(2021, 3, 4, 5, 6, 7, 123456789, time.UTC)}, want: `"2021-03-04T05:06:07.123"`},
+	}
+
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.in)
+		if err != nil {
+			t.Errorf("marshal %v returned error: %v", tt.in.Time, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("marshal %v = %s, want %s", tt.in.Time, got, tt.want)
+		}
+
+		var decoded Time
+		if err := json.Unmarshal(got, &decoded); err != nil {
+			t.Errorf("unmarshal %s returned error: %v", got, err)
+		} else if !decoded.Equal(tt.in.Truncate(time.Millisecond)) {
+			t.Errorf("round trip of %v = %v", tt.in.Time, decoded.Time)
+		}
+	}
+}
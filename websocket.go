@@ -1,6 +1,9 @@
 package deconz
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // WebsocketUpdate contains the data deserialized from the async channel
 type WebsocketUpdate struct {
@@ -10,6 +13,12 @@ type WebsocketUpdate struct {
 	GroupState  *GroupState
 	LightState  *LightState
 	SensorState *SensorState
+	// SensorStateErr is set, to a DecodeError, if a sensor state couldn't be decoded into SensorState.
+	// The update is still returned in this case; DecodeSensor may be able to decode the typed state.
+	SensorStateErr error
+	// WindowCoveringState is also filled in if the light state contains window covering fields.
+	// Like the other states, only the fields included in the update are set
+	WindowCoveringState *WindowCoveringState
 
 	// These are conditionally filled in by parsing the relevant json.RawMessage field
 	Group  *Group
@@ -26,18 +35,18 @@ type WebsocketUpdateMetadata struct {
 	UniqueID   string `json:"uniqueid"`
 
 	// The following are set on `changed` events
-	Config json.RawMessage `json:"config"`
+	Config json.RawMessage `json:"config,omitempty"`
 	Name   string          `json:"name"`
-	State  json.RawMessage `json:"state"`
+	State  json.RawMessage `json:"state,omitempty"`
 
 	// The following fields are only set on `scene-called` events
 	GroupID string `json:"gid"`
 	SceneID string `json:"scid"`
 
 	// The following fields are set on the `added` event for the relevant resource type
-	Group  json.RawMessage `json:"group"`
-	Light  json.RawMessage `json:"light"`
-	Sensor json.RawMessage `json:"sensor"`
+	Group  json.RawMessage `json:"group,omitempty"`
+	Light  json.RawMessage `json:"light,omitempty"`
+	Sensor json.RawMessage `json:"sensor,omitempty"`
 }
 
 // UnmarshalJSON allows us to conditionally deserialize the websocket update
@@ -52,14 +61,15 @@ func (wsu *WebsocketUpdate) UnmarshalJSON(b []byte) error {
 	wsu.Meta = meta
 
 	if meta.Resource == "sensors" {
-		if meta.Event == "changed" {
+		if meta.Event == "changed" && len(meta.State) > 0 {
+			// The generic state is best effort; types it doesn't describe may use the same keys with other value types.
+			// DecodeSensor provides the typed state.
 			state := &SensorState{}
-			err = json.Unmarshal(meta.State, state)
-			if err != nil {
-				return err
+			if err := json.Unmarshal(meta.State, state); err != nil {
+				wsu.SensorStateErr = &DecodeError{Err: err}
+			} else {
+				wsu.SensorState = state
 			}
-
-			wsu.SensorState = state
 		} else if meta.Event == "added" {
 			sensor := &Sensor{}
 			err = json.Unmarshal(meta.Sensor, sensor)
@@ -70,7 +80,7 @@ func (wsu *WebsocketUpdate) UnmarshalJSON(b []byte) error {
 			wsu.Sensor = sensor
 		}
 	} else if meta.Resource == "lights" {
-		if meta.Event == "changed" {
+		if meta.Event == "changed" && len(meta.State) > 0 {
 			state := &LightState{}
 			err = json.Unmarshal(meta.State, state)
 			if err != nil {
@@ -78,6 +88,7 @@ func (wsu *WebsocketUpdate) UnmarshalJSON(b []byte) error {
 			}
 
 			wsu.LightState = state
+			wsu.WindowCoveringState = windowCoveringUpdate(meta.State, state)
 		} else if meta.Event == "added" {
 			light := &Light{}
 			err = json.Unmarshal(meta.Light, light)
@@ -88,7 +99,7 @@ func (wsu *WebsocketUpdate) UnmarshalJSON(b []byte) error {
 			wsu.Light = light
 		}
 	} else if meta.Resource == "groups" {
-		if meta.Event == "changed" {
+		if meta.Event == "changed" && len(meta.State) > 0 {
 			state := &GroupState{}
 			err = json.Unmarshal(meta.State, state)
 			if err != nil {
@@ -109,3 +120,30 @@ func (wsu *WebsocketUpdate) UnmarshalJSON(b []byte) error {
 
 	return nil
 }
+
+// DecodeSensor decodes the sensor in an update using the decoder registered for the sensor type.
+// The websocket doesn't include the type in 'changed' updates, so it has to be supplied;
+// it is available from the cached sensor with the same ID. Only the fields included in the update are set.
+func (wsu *WebsocketUpdate) DecodeSensor(sensorType string) (*Sensor, error) {
+	if wsu.Meta.Resource != "sensors" {
+		return nil, fmt.Errorf("%w: update is for %s, not sensors", ErrWrongResourceType, wsu.Meta.Resource)
+	}
+	if wsu.Sensor != nil {
+		return wsu.Sensor, nil
+	}
+
+	sensor := &Sensor{
+		SensorMetadata: SensorMetadata{
+			ID:        wsu.Meta.ResourceID,
+			Name:      wsu.Meta.Name,
+			Type:      sensorType,
+			UniqueID:  wsu.Meta.UniqueID,
+			StateRaw:  wsu.Meta.State,
+			ConfigRaw: wsu.Meta.Config,
+		},
+	}
+	if err := sensor.Decode(); err != nil {
+		return nil, err
+	}
+	return sensor, nil
+}
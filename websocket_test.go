@@ -0,0 +1,33 @@
+package deconz
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWebsocketUpdateSensorState(t *testing.T) {
+	update := &WebsocketUpdate{}
+	msg := `{"t":"event","e":"changed","r":"sensors","id":"4","state":{"temperature":2150,"lastupdated":"2021-03-04T05:06:07"}}`
+	if err := json.Unmarshal([]byte(msg), update); err != nil {
+		t.Fatalf("unmarshal returned error: %v", err)
+	}
+	if update.SensorStateErr != nil || update.SensorState == nil || update.SensorState.Temperature != 2150 {
+		t.Errorf("update = %+v, want the temperature without an error", update)
+	}
+
+	// A state the generic fields can't describe is recorded rather than failing the update
+	update = &WebsocketUpdate{}
+	msg = `{"t":"event","e":"changed","r":"sensors","id":"4","state":{"temperature":"warm"}}`
+	if err := json.Unmarshal([]byte(msg), update); err != nil {
+		t.Fatalf("unmarshal returned error: %v", err)
+	}
+	if update.SensorState != nil {
+		t.Errorf("SensorState = %+v, want nil", update.SensorState)
+	}
+	if !IsDecodeError(update.SensorStateErr) {
+		t.Errorf("SensorStateErr = %v, want a decode error", update.SensorStateErr)
+	}
+	if update.Meta.ResourceID != "4" || len(update.Meta.State) == 0 {
+		t.Errorf("Meta = %+v, want the resource and raw state kept", update.Meta)
+	}
+}
@@ -0,0 +1,193 @@
+package deconz
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultMinBackoff   = time.Second
+	defaultMaxBackoff   = time.Minute
+	defaultPingInterval = 30 * time.Second
+	// pingWriteTimeout bounds sending a ping, so a stalled connection can't block the pinger.
+	pingWriteTimeout = 5 * time.Second
+)
+
+// WebsocketClient maintains a connection to the gateway websocket and delivers the decoded updates.
+// The connection is re-established, with backoff, whenever it is lost.
+type WebsocketClient struct {
+	client *Client
+
+	// Dialer is used to open the websocket connection.
+	Dialer *websocket.Dialer
+	// MinBackoff is the time waited before the first reconnection attempt.
+	MinBackoff time.Duration
+	// MaxBackoff is the longest time waited between reconnection attempts.
+	MaxBackoff time.Duration
+	// PingInterval is the time between the pings sent to the gateway. The connection is treated as lost,
+	// and reopened, if nothing is received from the gateway for two intervals; the default is used if it isn't positive.
+	PingInterval time.Duration
+
+	// OnConnect is called, if set, every time the websocket connection is established.
+	OnConnect func()
+	// OnError is called, if set, with the reason the websocket connection was lost or could not be opened.
+	// It is also called with a DecodeError if a message could not be parsed; the connection is kept in this case.
+	OnError func(error)
+}
+
+// NewWebsocketClient creates a new websocket client which uses the supplied API client to discover the websocket port.
+func NewWebsocketClient(c *Client) *WebsocketClient {
+	return &WebsocketClient{
+		client:       c,
+		Dialer:       websocket.DefaultDialer,
+		MinBackoff:   defaultMinBackoff,
+		MaxBackoff:   defaultMaxBackoff,
+		PingInterval: defaultPingInterval,
+	}
+}
+
+// Run connects to the gateway websocket and calls the handler for every update received.
+// It blocks until the context is cancelled, reconnecting as required, and returns the context error.
+func (wc *WebsocketClient) Run(ctx context.Context, handler func(*WebsocketUpdate)) error {
+	// Without a backoff, a gateway which refuses connections would be retried in a busy loop
+	minBackoff := wc.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultMinBackoff
+	}
+	maxBackoff := wc.MaxBackoff
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+	backoff := minBackoff
+
+	for {
+		connected, err := wc.runOnce(ctx, handler)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if wc.OnError != nil && err != nil {
+			wc.OnError(err)
+		}
+
+		if connected {
+			backoff = minBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// Listen connects to the gateway websocket and returns a channel the updates are delivered on.
+// The channel is closed once the context is cancelled.
+func (wc *WebsocketClient) Listen(ctx context.Context) <-chan *WebsocketUpdate {
+	updates := make(chan *WebsocketUpdate)
+
+	go func() {
+		defer close(updates)
+
+		wc.Run(ctx, func(update *WebsocketUpdate) {
+			select {
+			case updates <- update:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	return updates
+}
+
+// runOnce opens a single websocket connection and reads from it until it fails or the context is cancelled.
+// The returned bool indicates whether the connection was successfully established.
+func (wc *WebsocketClient) runOnce(ctx context.Context, handler func(*WebsocketUpdate)) (bool, error) {
+	gwState, err := wc.client.GetGatewayState(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	wsu := url.URL{
+		Scheme: "ws",
+		Host:   wc.client.hostname + ":" + strconv.Itoa(gwState.WebsocketPort),
+	}
+
+	conn, _, err := wc.Dialer.DialContext(ctx, wsu.String(), nil)
+	if err != nil {
+		return false, &TransportError{Err: err}
+	}
+	defer conn.Close()
+
+	// Closing the connection is the only way to unblock a pending read when the context is cancelled.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	// A half-open connection never returns from a read, so the gateway is pinged and the read deadline
+	// is extended every time it answers or sends a message.
+	pingInterval := wc.PingInterval
+	if pingInterval <= 0 {
+		pingInterval = defaultPingInterval
+	}
+	readTimeout := 2 * pingInterval
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+	go wc.ping(conn, pingInterval, done)
+
+	if wc.OnConnect != nil {
+		wc.OnConnect()
+	}
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return true, &TransportError{Err: err}
+		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+		update := &WebsocketUpdate{}
+		if err := json.Unmarshal(msg, update); err != nil {
+			if wc.OnError != nil {
+				wc.OnError(&DecodeError{Err: err})
+			}
+			continue
+		}
+
+		handler(update)
+	}
+}
+
+// ping sends a ping to the gateway every interval until done is closed.
+// A ping which can't be sent is ignored; the read deadline detects the lost connection.
+func (wc *WebsocketClient) ping(conn *websocket.Conn, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pingWriteTimeout))
+		}
+	}
+}
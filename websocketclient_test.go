@@ -0,0 +1,74 @@
+package deconz_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rmrobinson/deconz-go"
+	"github.com/rmrobinson/deconz-go/deconztest"
+)
+
+func TestWebsocketClientKeepsAnsweredConnection(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+	id := server.AddLight(deconz.Light{Name: "Hall", Type: "Dimmable light", UniqueID: "00:11:22:33:44:55:66:77-01"})
+
+	var connects int32
+	connected := make(chan struct{}, 10)
+	wc := deconz.NewWebsocketClient(server.Client())
+	wc.PingInterval = 20 * time.Millisecond
+	wc.OnConnect = func() {
+		atomic.AddInt32(&connects, 1)
+		connected <- struct{}{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := wc.Listen(ctx)
+
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("websocket didn't connect")
+	}
+
+	// Nothing is sent for several read timeouts; the pongs must keep the connection open
+	time.Sleep(200 * time.Millisecond)
+	server.UpdateLightState(id, map[string]interface{}{"on": true})
+
+	select {
+	case update := <-updates:
+		if update.Meta.ResourceID != id {
+			t.Errorf("update for %s %s, want light %s", update.Meta.Resource, update.Meta.ResourceID, id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update received")
+	}
+	if n := atomic.LoadInt32(&connects); n != 1 {
+		t.Errorf("connected %d times, want the connection kept", n)
+	}
+}
+
+func TestWebsocketClientZeroBackoff(t *testing.T) {
+	server := deconztest.NewServer()
+	defer server.Close()
+
+	var connects int32
+	wc := deconz.NewWebsocketClient(server.Client())
+	wc.MinBackoff = 0
+	wc.OnConnect = func() {
+		atomic.AddInt32(&connects, 1)
+		time.AfterFunc(10*time.Millisecond, server.DropWebsockets)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	wc.Run(ctx, func(*deconz.WebsocketUpdate) {})
+
+	// Every connection is dropped at once, so without a minimum backoff it would reconnect continuously
+	if n := atomic.LoadInt32(&connects); n > 1 {
+		t.Errorf("reconnected %d times in 500ms, want a backoff between attempts", n)
+	}
+}
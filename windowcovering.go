@@ -0,0 +1,88 @@
+package deconz
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// WindowCoveringState contains the state of a blind, shade or curtain.
+// The gateway doesn't report whether the covering is moving; the lift is updated as it moves instead.
+// Fields which weren't reported are nil, as websocket updates only contain the fields which changed.
+type WindowCoveringState struct {
+	// Open is true unless the covering is fully closed
+	Open *bool
+	// Lift contains how far the covering is closed, from 0 (open) to 100 (closed)
+	Lift *int
+	// Tilt contains the slat angle, from 0 to 100
+	Tilt      *int
+	Reachable *bool
+}
+
+// IsWindowCovering returns true if the light is a window covering, rather than a lighting device.
+func (l *Light) IsWindowCovering() bool {
+	return l.Capabilities().Class == LightClassWindowCovering
+}
+
+// WindowCovering returns the light state as the state of a window covering.
+// Open is derived from the lift if the gateway didn't report it.
+func (ls LightState) WindowCovering() WindowCoveringState {
+	wcs := WindowCoveringState{
+		Reachable: Bool(ls.Reachable),
+	}
+	if ls.Lift != nil {
+		wcs.Lift = Int(*ls.Lift)
+		wcs.Open = Bool(*ls.Lift < 100)
+	}
+	if ls.Open != nil {
+		wcs.Open = Bool(*ls.Open)
+	}
+	if ls.Tilt != nil {
+		wcs.Tilt = Int(*ls.Tilt)
+	}
+	return wcs
+}
+
+// windowCoveringUpdate returns the window covering state of a partial light state payload,
+// or nil if it doesn't contain any window covering fields. Reachable is nil unless the payload contains it.
+func windowCoveringUpdate(raw json.RawMessage, state *LightState) *WindowCoveringState {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+
+	for _, k := range []string{"open", "lift", "tilt"} {
+		if _, ok := fields[k]; ok {
+			wcs := state.WindowCovering()
+			if _, ok := fields["reachable"]; !ok {
+				wcs.Reachable = nil
+			}
+			return &wcs
+		}
+	}
+	return nil
+}
+
+// OpenWindowCovering fully opens the specified window covering.
+func (c *Client) OpenWindowCovering(ctx context.Context, id string) (UpdateResults, error) {
+	return c.SetLightState(ctx, id, NewLightState().WithOpen(true))
+}
+
+// CloseWindowCovering fully closes the specified window covering.
+func (c *Client) CloseWindowCovering(ctx context.Context, id string) (UpdateResults, error) {
+	return c.SetLightState(ctx, id, NewLightState().WithOpen(false))
+}
+
+// StopWindowCovering halts the specified window covering if it is moving.
+func (c *Client) StopWindowCovering(ctx context.Context, id string) (UpdateResults, error) {
+	return c.SetLightState(ctx, id, NewLightState().WithStop())
+}
+
+// SetWindowCoveringLift moves the specified window covering to the given percentage closed, from 0 to 100.
+func (c *Client) SetWindowCoveringLift(ctx context.Context, id string, lift int) (UpdateResults, error) {
+	return c.SetLightState(ctx, id, NewLightState().WithLift(lift))
+}
+
+// SetWindowCoveringTilt turns the slats of the specified window covering to the given percentage, from 0 to 100.
+func (c *Client) SetWindowCoveringTilt(ctx context.Context, id string, tilt int) (UpdateResults, error) {
+	return c.SetLightState(ctx, id, NewLightState().WithTilt(tilt))
+}
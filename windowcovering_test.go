@@ -0,0 +1,57 @@
+package deconz
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWindowCoveringUpdate(t *testing.T) {
+	tests := []struct {
+		name      string
+		msg       string
+		open      bool
+		lift      int
+		reachable *bool
+	}{
+		{
+			name: "lift only",
+			msg:  `{"t":"event","e":"changed","r":"lights","id":"3","state":{"lift":100}}`,
+			open: false,
+			lift: 100,
+		},
+		{
+			name:      "unreachable",
+			msg:       `{"t":"event","e":"changed","r":"lights","id":"3","state":{"lift":40,"reachable":false}}`,
+			open:      true,
+			lift:      40,
+			reachable: Bool(false),
+		},
+	}
+
+	for _, tt := range tests {
+		update := &WebsocketUpdate{}
+		if err := json.Unmarshal([]byte(tt.msg), update); err != nil {
+			t.Errorf("%s: unmarshal returned error: %v", tt.name, err)
+			continue
+		}
+		wcs := update.WindowCoveringState
+		if wcs == nil {
+			t.Errorf("%s: no window covering state", tt.name)
+			continue
+		}
+		if wcs.Open == nil || *wcs.Open != tt.open || wcs.Lift == nil || *wcs.Lift != tt.lift || wcs.Tilt != nil {
+			t.Errorf("%s: state = %+v, want open %t and lift %d without a tilt", tt.name, wcs, tt.open, tt.lift)
+		}
+		if (wcs.Reachable == nil) != (tt.reachable == nil) || (wcs.Reachable != nil && *wcs.Reachable != *tt.reachable) {
+			t.Errorf("%s: reachable = %v, want %v", tt.name, wcs.Reachable, tt.reachable)
+		}
+	}
+
+	update := &WebsocketUpdate{}
+	if err := json.Unmarshal([]byte(`{"t":"event","e":"changed","r":"lights","id":"1","state":{"on":true}}`), update); err != nil {
+		t.Fatalf("unmarshal returned error: %v", err)
+	}
+	if update.WindowCoveringState != nil {
+		t.Errorf("light update has window covering state %+v, want nil", update.WindowCoveringState)
+	}
+}